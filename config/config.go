@@ -3,14 +3,40 @@ package config
 var SemVer = "0.1.0"
 
 type Config struct {
-	Name      string
-	All       bool
-	Directory string `mapstructure:"dir"`
-	FileName  string
-	Case      string
-	KeepTree  bool
-	Recursive bool
-	Output    string
-	Version   bool
-	Format    string
+	Name string
+	// Values selects package constants and variables the way Name selects structs
+	Values      string
+	All         bool
+	Directories []string `mapstructure:"dir"`
+	// Patterns are go package patterns given as arguments
+	Patterns   []string
+	FileName   string
+	Case       string
+	KeepTree   bool
+	Recursive  bool
+	Output     string
+	Version    bool
+	Format     string
+	KeySource  string `mapstructure:"key-source"`
+	MaxDepth   int    `mapstructure:"max-depth"`
+	References bool   `mapstructure:"refs"`
+	Mode       string
+	// Doc is the rendering of doc comments [raw, plain, markdown, html]
+	Doc          string
+	Stdin        string
+	IncludeTests bool `mapstructure:"include-tests"`
+	// IncludeUnexported also generates info for unexported fields and methods
+	IncludeUnexported bool `mapstructure:"include-unexported"`
+	// ExcludeUnexportedFields leaves unexported fields out of formats
+	// instead of marking them
+	ExcludeUnexportedFields bool `mapstructure:"exclude-unexported-fields"`
+	// ExcludeUnexportedMethods leaves unexported methods out of formats
+	// instead of marking them
+	ExcludeUnexportedMethods bool `mapstructure:"exclude-unexported-methods"`
+	Tags                     []string
+	GOOS                     string   `mapstructure:"goos"`
+	GOARCH                   string   `mapstructure:"goarch"`
+	BuildConfigs             []string `mapstructure:"build-config"`
+	Qualifier                string
+	Aliases                  map[string]string `mapstructure:"alias"`
 }
@@ -0,0 +1,208 @@
+package gens
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/token"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// BuildConfig is a build configuration packages are loaded under, empty
+// fields fall back to the environment
+type BuildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// ParseBuildConfig parses a build configuration written as GOOS/GOARCH
+// optionally followed by build tags joined with "+", e.g. linux/amd64:jsoniter+integration
+func ParseBuildConfig(value string) (BuildConfig, error) {
+	var b BuildConfig
+
+	platform := value
+	if index := strings.Index(value, ":"); index != -1 {
+		platform = value[:index]
+		for _, tag := range strings.Split(value[index+1:], "+") {
+			if tag != "" {
+				b.Tags = append(b.Tags, tag)
+			}
+		}
+	}
+
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 {
+		return b, fmt.Errorf("invalid build configuration %q, expected GOOS/GOARCH[:tag+tag]", value)
+	}
+	b.GOOS, b.GOARCH = parts[0], parts[1]
+
+	return b, nil
+}
+
+// Name returns a directory name identifying the configuration
+func (b BuildConfig) Name() string {
+	parts := make([]string, 0, 2+len(b.Tags))
+	for _, part := range append([]string{b.GOOS, b.GOARCH}, b.Tags...) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// constraints indexes build constraints of declared types and struct fields
+// by position, only declarations of constrained files are recorded
+type constraints map[token.Pos]string
+
+// fileConstraint returns the build constraint of file as a //go:build
+// expression, combining its constraint comments with the GOOS and GOARCH
+// suffixes of fileName. It returns an empty string for unconstrained files
+func fileConstraint(file *ast.File, fileName string) string {
+	var goBuild, plusBuild constraint.Expr
+
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					goBuild = expr
+				}
+			case constraint.IsPlusBuild(c.Text):
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					plusBuild = and(plusBuild, expr)
+				}
+			}
+		}
+	}
+
+	// //go:build lines supersede // +build lines
+	expr := goBuild
+	if expr == nil {
+		expr = plusBuild
+	}
+
+	expr = and(expr, fileNameConstraint(fileName, expr))
+	if expr == nil {
+		return ""
+	}
+	return expr.String()
+}
+
+// fileNameConstraint returns the constraint implied by _GOOS, _GOARCH or
+// _GOOS_GOARCH suffixes of fileName, leaving out tags expr already requires.
+// Which suffixes count is left to go/build
+func fileNameConstraint(fileName string, expr constraint.Expr) constraint.Expr {
+	name, _, _ := strings.Cut(filepath.Base(fileName), ".")
+	name = strings.TrimSuffix(name, "_test")
+
+	index := strings.Index(name, "_")
+	if index == -1 {
+		return nil
+	}
+
+	var tags []string
+	parts := strings.Split(name[index+1:], "_")
+	n := len(parts)
+	switch {
+	case !knownTag(parts[n-1]):
+	case n >= 2 && !matchFileName("none", parts[n-1], parts[n-2]+"_"+parts[n-1]):
+		// only a _GOOS_GOARCH pair fails once GOOS does not match
+		tags = parts[n-2:]
+	default:
+		tags = parts[n-1:]
+	}
+
+	var suffix constraint.Expr
+	for _, tag := range tags {
+		if expr == nil || !requires(expr, tag) {
+			suffix = and(suffix, &constraint.TagExpr{Tag: tag})
+		}
+	}
+	return suffix
+}
+
+// requires reports whether expr can only be satisfied with tag set. Tags go/build
+// sets along with GOOS are taken into account, android implies linux and ios
+// implies darwin. Expressions with too many tags to try are assumed not to
+func requires(expr constraint.Expr, tag string) bool {
+	tags := []string{tag}
+	expr.Eval(func(t string) bool {
+		if !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+		return false
+	})
+	if len(tags) > 16 {
+		return false
+	}
+
+	set := make(map[string]bool, len(tags))
+	for assignment := 0; assignment < 1<<len(tags); assignment++ {
+		for loop, t := range tags {
+			set[t] = assignment&(1<<loop) != 0
+		}
+		if set[tag] || !possible(set) {
+			continue
+		}
+		if expr.Eval(func(t string) bool { return set[t] }) {
+			return false
+		}
+	}
+	return true
+}
+
+// possible reports whether go/build can set the tags of set together, a GOOS
+// implying another is never set without it
+func possible(set map[string]bool) bool {
+	for goos, on := range set {
+		if !on {
+			continue
+		}
+		for implied, on := range set {
+			if !on && implied != goos && knownTag(implied) && matchFileName(goos, "none", implied) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// knownTag reports whether go/build treats tag as a GOOS or GOARCH file name suffix
+func knownTag(tag string) bool {
+	return !matchFileName("none", "none", tag)
+}
+
+// matchFileName reports whether go/build selects a file named after suffix
+// for goos and goarch
+func matchFileName(goos, goarch, suffix string) bool {
+	ctx := build.Context{
+		GOOS:     goos,
+		GOARCH:   goarch,
+		Compiler: "gc",
+		OpenFile: func(string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("package p\n")), nil
+		},
+	}
+
+	ok, err := ctx.MatchFile("", "f_"+suffix+".go")
+	return err == nil && ok
+}
+
+func and(x, y constraint.Expr) constraint.Expr {
+	switch {
+	case x == nil:
+		return y
+	case y == nil:
+		return x
+	}
+	return &constraint.AndExpr{X: x, Y: y}
+}
@@ -0,0 +1,192 @@
+package gens
+
+import (
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestParseBuildConfig(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     BuildConfig
+		wantName string
+		wantErr  bool
+	}{
+		{value: "linux/amd64", want: BuildConfig{GOOS: "linux", GOARCH: "amd64"}, wantName: "linux_amd64"},
+		{
+			value:    "windows/arm64:integration",
+			want:     BuildConfig{GOOS: "windows", GOARCH: "arm64", Tags: []string{"integration"}},
+			wantName: "windows_arm64_integration",
+		},
+		{
+			value:    "linux/amd64:jsoniter+integration",
+			want:     BuildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"jsoniter", "integration"}},
+			wantName: "linux_amd64_jsoniter_integration",
+		},
+		{value: "linux/amd64:", want: BuildConfig{GOOS: "linux", GOARCH: "amd64"}, wantName: "linux_amd64"},
+		{value: "linux/amd64:+a++b", want: BuildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"a", "b"}}, wantName: "linux_amd64_a_b"},
+		{value: "linux", wantErr: true},
+		{value: "linux/amd64/v2", wantErr: true},
+		{value: ":integration", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseBuildConfig(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBuildConfig(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBuildConfig(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			if name := got.Name(); name != tt.wantName {
+				t.Errorf("Name() = %q, want %q", name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestFileNameConstraint(t *testing.T) {
+	tests := []struct {
+		fileName string
+		expr     string
+		want     string
+	}{
+		{fileName: "fact.go", want: ""},
+		{fileName: "fact_linux.go", want: "linux"},
+		{fileName: "fact_amd64.go", want: "amd64"},
+		{fileName: "fact_linux_amd64.go", want: "linux && amd64"},
+		{fileName: "fact_windows_test.go", want: "windows"},
+		{fileName: "/src/fact/fact_windows_arm.go", want: "windows && arm"},
+		{fileName: "fact_unknown.go", want: ""},
+		{fileName: "linux.go", want: ""},
+		{fileName: "fact_linux_unknown.go", want: ""},
+		{fileName: "fact_unknown_amd64.go", want: "amd64"},
+		{fileName: "fact_linux_windows.go", want: "windows"},
+		{fileName: "fact.pb_linux.go", want: ""},
+		{fileName: "fact_windows.go", expr: "windows && !arm", want: ""},
+		{fileName: "fact_windows_arm.go", expr: "windows", want: "arm"},
+		{fileName: "fact_windows.go", expr: "windows || linux", want: "windows"},
+		{fileName: "fact_linux.go", expr: "(linux && amd64) || (linux && arm64)", want: ""},
+		{fileName: "fact_linux.go", expr: "android", want: ""},
+		{fileName: "fact_linux.go", expr: "android || linux", want: ""},
+		{fileName: "fact_linux.go", expr: "android || windows", want: "linux"},
+		{fileName: "fact_darwin_arm64.go", expr: "ios", want: "arm64"},
+		{fileName: "fact_android.go", expr: "linux", want: "android"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName+" "+tt.expr, func(t *testing.T) {
+			var expr constraint.Expr
+			if tt.expr != "" {
+				var err error
+				if expr, err = constraint.Parse("//go:build " + tt.expr); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := ""
+			if suffix := fileNameConstraint(tt.fileName, expr); suffix != nil {
+				got = suffix.String()
+			}
+			if got != tt.want {
+				t.Errorf("fileNameConstraint(%q, %q) = %q, want %q", tt.fileName, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileConstraint(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		src      string
+		want     string
+	}{
+		{
+			name:     "unconstrained",
+			fileName: "fact.go",
+			src:      "package fact\n",
+			want:     "",
+		},
+		{
+			name:     "go:build",
+			fileName: "fact.go",
+			src:      "//go:build linux && !cgo\n\npackage fact\n",
+			want:     "linux && !cgo",
+		},
+		{
+			name:     "go:build supersedes +build",
+			fileName: "fact.go",
+			src:      "//go:build linux\n// +build darwin\n\npackage fact\n",
+			want:     "linux",
+		},
+		{
+			name:     "single +build line",
+			fileName: "fact.go",
+			src:      "// +build linux darwin\n\npackage fact\n",
+			want:     "linux || darwin",
+		},
+		{
+			name:     "+build lines are joined",
+			fileName: "fact.go",
+			src:      "// +build linux darwin\n// +build amd64\n\npackage fact\n",
+			want:     "(linux || darwin) && amd64",
+		},
+		{
+			name:     "file name suffix",
+			fileName: "fact_windows.go",
+			src:      "package fact\n",
+			want:     "windows",
+		},
+		{
+			name:     "expression and suffix",
+			fileName: "fact_windows.go",
+			src:      "//go:build integration || e2e\n\npackage fact\n",
+			want:     "(integration || e2e) && windows",
+		},
+		{
+			name:     "suffix already required",
+			fileName: "fact_windows.go",
+			src:      "//go:build windows && !arm\n\npackage fact\n",
+			want:     "windows && !arm",
+		},
+		{
+			name:     "implied suffix",
+			fileName: "fact_linux.go",
+			src:      "//go:build android\n\npackage fact\n",
+			want:     "android",
+		},
+		{
+			name:     "malformed constraints are ignored",
+			fileName: "fact.go",
+			src:      "//go:build linux &&\n\npackage fact\n",
+			want:     "",
+		},
+		{
+			name:     "comments after the package clause are ignored",
+			fileName: "fact.go",
+			src:      "package fact\n\n//go:build linux\n",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), tt.fileName, tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fileConstraint(file, tt.fileName); got != tt.want {
+				t.Errorf("fileConstraint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package gens
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	DiagnosticList  = "list"
+	DiagnosticParse = "parse"
+	DiagnosticType  = "type"
+	DiagnosticOther = "other"
+)
+
+// Diagnostic is an error reported while loading the package of a struct,
+// info is still generated for everything that type-checks
+type Diagnostic struct {
+	// Pos is the file:line:column of the error, empty when unknown
+	Pos     string `json:",omitempty"`
+	Message string
+	Kind    string
+}
+
+func newDiagnostic(err packages.Error) *Diagnostic {
+	d := &Diagnostic{
+		Message: err.Msg,
+		Kind:    DiagnosticOther,
+	}
+
+	if err.Pos != "-" {
+		d.Pos = err.Pos
+	}
+
+	switch err.Kind {
+	case packages.ListError:
+		d.Kind = DiagnosticList
+	case packages.ParseError:
+		d.Kind = DiagnosticParse
+	case packages.TypeError:
+		d.Kind = DiagnosticType
+	}
+
+	return d
+}
+
+// in reports whether diagnostic is positioned in fileName or has no position
+func (d *Diagnostic) in(fileName string) bool {
+	return d.Pos == "" || strings.HasPrefix(d.Pos, fileName+":")
+}
+
+func (d *Diagnostic) String() string {
+	if d.Pos == "" {
+		return d.Message
+	}
+	return d.Pos + ": " + d.Message
+}
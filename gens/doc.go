@@ -0,0 +1,24 @@
+package gens
+
+const (
+	// DocRaw keeps doc comments as written
+	DocRaw      = "raw"
+	DocPlain    = "plain"
+	DocMarkdown = "markdown"
+	DocHTML     = "html"
+)
+
+// docLinkBaseURL is where doc links to types outside of the run point to
+const docLinkBaseURL = "https://pkg.go.dev"
+
+// DocRenderer renders doc comments with go doc comment semantics: headings,
+// code blocks, lists and [Type] doc links
+type DocRenderer struct {
+	// Format is DocPlain, DocMarkdown or DocHTML, comments are kept as
+	// written otherwise
+	Format string
+	// Link returns the URL of the info of type name declared in toFile, seen
+	// from the info of a type declared in fromFile. Links it does not
+	// resolve point to pkg.go.dev
+	Link func(fromFile, name, toFile string) (string, bool)
+}
@@ -0,0 +1,67 @@
+package gens
+
+import (
+	"go/doc/comment"
+	"go/types"
+)
+
+// render renders text, a doc comment of pkg, in the format of r. link
+// returns the URL of a type of the run by package path and name
+func (r *DocRenderer) render(text string, pkg *types.Package, link func(pkgPath, name string) (string, bool)) string {
+	parser := comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			if name == pkg.Name() {
+				return pkg.Path(), true
+			}
+			for _, imported := range pkg.Imports() {
+				if imported.Name() == name {
+					return imported.Path(), true
+				}
+			}
+			return "", false
+		},
+		LookupSym: func(recv, name string) bool {
+			if recv == "" {
+				return pkg.Scope().Lookup(name) != nil
+			}
+
+			obj := pkg.Scope().Lookup(recv)
+			if obj == nil {
+				return false
+			}
+			found, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pkg, name)
+			return found != nil
+		},
+	}
+	doc := parser.Parse(text)
+
+	printer := comment.Printer{
+		// keeps lines as written instead of wrapping them at 80 columns
+		TextWidth: -1,
+		DocLinkURL: func(l *comment.DocLink) string {
+			target := *l
+			if target.ImportPath == "" {
+				target.ImportPath = pkg.Path()
+			}
+
+			name := target.Name
+			if target.Recv != "" {
+				name = target.Recv
+			}
+			if url, ok := link(target.ImportPath, name); ok {
+				return url
+			}
+			return target.DefaultURL(docLinkBaseURL)
+		},
+	}
+
+	switch r.Format {
+	case DocPlain:
+		return string(printer.Text(doc))
+	case DocMarkdown:
+		return string(printer.Markdown(doc))
+	case DocHTML:
+		return string(printer.HTML(doc))
+	}
+	return text
+}
@@ -0,0 +1,63 @@
+package gens
+
+import (
+	"go/types"
+	"sort"
+)
+
+// Enum lists the typed constants declared for a named basic type
+type Enum struct {
+	Type   string
+	Values []*EnumValue
+}
+
+type EnumValue struct {
+	Name        string
+	Value       string
+	Description string `json:",omitempty"`
+}
+
+// newEnum returns constants of named declared in its package, nil if named
+// is not a basic type or has no constant
+func newEnum(named *types.Named, docs docs) *Enum {
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+
+	var (
+		scope  = named.Obj().Pkg().Scope()
+		consts = make([]*types.Const, 0)
+	)
+
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !c.Exported() || !types.Identical(c.Type(), named) {
+			continue
+		}
+		consts = append(consts, c)
+	}
+
+	if len(consts) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+
+	enum := &Enum{
+		Type:   basic.Name(),
+		Values: make([]*EnumValue, 0, len(consts)),
+	}
+
+	for _, c := range consts {
+		enum.Values = append(enum.Values, &EnumValue{
+			Name:        c.Name(),
+			Value:       constantString(c.Val()),
+			Description: docs[c.Pos()],
+		})
+	}
+
+	return enum
+}
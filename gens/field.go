@@ -2,15 +2,32 @@ package gens
 
 import (
 	"go/types"
+	"strings"
 )
 
-type Type interface {
-	String() string
-}
+const (
+	KeySourceGoName = "go-name"
+)
 
 type Field struct {
 	Comment string
-	_var    *types.Var
+	Tag     Tag
+	// RawTag is the struct tag field is declared with
+	RawTag string
+	// Path lists the embedded fields a promoted field is selected through
+	Path []string
+	// Constraint is the build constraint of the file field is declared in,
+	// empty when the field exists under every configuration
+	Constraint string
+	// Source is the type expression field is declared with
+	Source      string
+	_var        *types.Var
+	docs        docs
+	constraints constraints
+	sources     sources
+	// unexported includes unexported fields of the struct of field
+	unexported bool
+	qualifier  Qualifier
 }
 
 // Name returns name of field
@@ -18,9 +35,60 @@ func (f *Field) Name() string {
 	return f._var.Name()
 }
 
+// Exported reports whether field is exported
+func (f *Field) Exported() bool {
+	return f._var.Exported()
+}
+
+// Key returns the name the field is emitted with
+func (f *Field) Key() string {
+	key, _ := f.KeyFrom(KeySourceGoName)
+	return key
+}
+
+// KeyFrom returns the name the field is emitted with when keys come from
+// source, either the Go name or the key of a struct tag like json or yaml.
+// It returns false when the tag excludes the field with "-"
+func (f *Field) KeyFrom(source string) (string, bool) {
+	if f.Tag.Name != "" {
+		return f.Tag.Name, true
+	}
+
+	if source == "" || source == KeySourceGoName {
+		return f.Name(), true
+	}
+
+	value, ok := f.Tags()[source]
+	if !ok {
+		return f.Name(), true
+	}
+
+	name := strings.Split(value, ",")[0]
+	if name == "-" && value == "-" {
+		return "", false
+	}
+	if name == "" {
+		return f.Name(), true
+	}
+	return name, true
+}
+
+// Tags returns key value pairs of the struct tag of field
+func (f *Field) Tags() map[string]string {
+	return ParseStructTag(f.RawTag)
+}
+
+// Description returns the inline description of field, fallback to its comment
+func (f *Field) Description() string {
+	if f.Tag.Desc != "" {
+		return f.Tag.Desc
+	}
+	return f.Comment
+}
+
 // Type returns type of field
 func (f *Field) Type() Type {
-	return f
+	return newType(f._var.Type())
 }
 
 func (f *Field) Struct() *Struct {
@@ -29,38 +97,34 @@ func (f *Field) Struct() *Struct {
 	)
 
 	if ss, ok := f._var.Type().Underlying().(*types.Pointer); ok {
-		if n, ok := ss.Elem().(*types.Named); ok {
+		if n, ok := types.Unalias(ss.Elem()).(*types.Named); ok {
 			named = n
 		}
 	}
 
-	if n, ok := f._var.Type().(*types.Named); ok {
+	if n, ok := types.Unalias(f._var.Type()).(*types.Named); ok {
 		named = n
 	}
 
 	if named != nil {
-		name := named.Obj().Name()
-
-		str := &Struct{
-			Name:    name,
-			named:   named,
-			methods: []*Method{},
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return nil
 		}
 
-		n2 := named.NumMethods()
-		// prevPos := 0
-		for loop := 0; loop < n2; loop++ {
-			f := named.Method(loop)
-			sig, ok := f.Type().Underlying().(*types.Signature)
-			if !ok {
-				continue
-			}
-
-			method := &Method{_func: f, signature: sig}
-			str.methods = append(str.methods, method)
-		}
 		return &Struct{
-			named: named,
+			Name:        named.Obj().Name(),
+			Kind:        KindStruct,
+			Comment:     f.docs[named.Obj().Pos()],
+			TypeParams:  typeParams(named, f.qualifier.For(named.Obj().Pkg())),
+			TypeArgs:    typeArgs(named, f.qualifier.For(named.Obj().Pkg())),
+			pkg:         named.Obj().Pkg(),
+			named:       named,
+			methods:     newMethods(named, f.docs, f.sources),
+			docs:        f.docs,
+			constraints: f.constraints,
+			sources:     f.sources,
+			unexported:  f.unexported,
+			qualifier:   f.qualifier,
 		}
 	}
 	// if named, ok := f._var.Type().(*types.Named); ok {
@@ -70,6 +134,25 @@ func (f *Field) Struct() *Struct {
 	return nil
 }
 
+// Enum returns typed constants declared for the named basic type of field,
+// or of its elements for pointers and slices
+func (f *Field) Enum() *Enum {
+	typ := types.Unalias(f._var.Type())
+	switch t := typ.(type) {
+	case *types.Pointer:
+		typ = t.Elem()
+	case *types.Slice:
+		typ = t.Elem()
+	}
+
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return nil
+	}
+
+	return newEnum(named, f.docs)
+}
+
 func (f *Field) String() string {
 	return f._var.Type().String()
 }
@@ -2,234 +2,606 @@ package gens
 
 import (
 	"encoding/json"
+	"go/types"
+	"sort"
 	"strings"
 )
 
 const (
-	Depth = 4
+	// RootReference is the $ref of the struct info is generated for
+	RootReference = "#"
 )
 
+// FormatOptions controls how formats lay out fields
+type FormatOptions struct {
+	// KeySource names field keys, see Field.KeyFrom
+	KeySource string
+	// MaxDepth limits levels of nested structs, 0 means no limit
+	MaxDepth int
+	// References emits each named struct once, later occurrences are
+	// written as $ref holding the JSON pointer to the first one. JF1
+	// flattens nested structs, it has no object to point to and ignores it
+	References bool
+	// Qualifier names packages in type strings
+	Qualifier Qualifier
+	// ExcludeUnexportedFields leaves out unexported fields of structs parsed
+	// with them, they are marked Unexported otherwise
+	ExcludeUnexportedFields bool
+	// ExcludeUnexportedMethods leaves out unexported methods the same way
+	ExcludeUnexportedMethods bool
+}
+
+// expands reports whether a nested struct at depth is laid out
+func (o FormatOptions) expands(depth int) bool {
+	return o.MaxDepth <= 0 || depth < o.MaxDepth
+}
+
+// visibleFields returns fields laid out with the options
+func (o FormatOptions) visibleFields(fields []*Field) []*Field {
+	if !o.ExcludeUnexportedFields {
+		return fields
+	}
+
+	visible := make([]*Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Exported() {
+			visible = append(visible, f)
+		}
+	}
+	return visible
+}
+
+// visibleMethods returns methods laid out with the options
+func (o FormatOptions) visibleMethods(methods []*Method) []*Method {
+	if !o.ExcludeUnexportedMethods {
+		return methods
+	}
+
+	visible := make([]*Method, 0, len(methods))
+	for _, m := range methods {
+		if m.Exported() {
+			visible = append(visible, m)
+		}
+	}
+	return visible
+}
+
+type reference struct {
+	Ref string `json:"$ref"`
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer returns the JSON pointer ref extended with tokens, see RFC 6901
+func pointer(ref string, tokens ...string) string {
+	for _, t := range tokens {
+		ref += "/" + pointerEscaper.Replace(t)
+	}
+	return ref
+}
+
+// nesting tracks named structs laid out by a format to break cycles and
+// emit references
+type nesting struct {
+	visiting map[string]bool
+	refs     map[string]string
+}
+
+func newNesting(root string) *nesting {
+	return &nesting{
+		visiting: map[string]bool{root: true},
+		refs:     map[string]string{root: RootReference},
+	}
+}
+
+// enter returns the reference to write instead of laying out str at the
+// JSON pointer path, ok is false when str is already being laid out or,
+// with references, was laid out before
+func (n *nesting) enter(str *Struct, path string, references bool) (ref string, ok bool) {
+	key := str.String()
+	if n.visiting[key] {
+		return n.refs[key], false
+	}
+
+	if references {
+		if ref, found := n.refs[key]; found {
+			return ref, false
+		}
+		n.refs[key] = path
+	}
+
+	n.visiting[key] = true
+	return "", true
+}
+
+func (n *nesting) leave(str *Struct) {
+	delete(n.visiting, str.String())
+}
+
+// Format lays out the info of a struct
 type Format interface {
-	SetMethods([]*Method)
-	SetFields([]*Field)
+	SetOptions(FormatOptions)
+	SetStruct(*Struct)
 	Format() string
 	Extension() string
 }
 
 type methodType struct {
-	Name        string `json:"-"`
-	Description string
-	Params      []fieldType
-	Return      fieldType
-	Variadic    bool
+	Name         string `json:"-"`
+	Description  string
+	Params       []fieldType
+	Return       fieldType
+	Results      []fieldType
+	Variadic     bool
+	Receiver     string `json:",omitempty"`
+	ReturnsError bool
+	TakesContext bool
+	Promoted     string `json:",omitempty"`
+	// Constructs is the struct a package-level function builds
+	Constructs string `json:",omitempty"`
+	Unexported bool   `json:",omitempty"`
+}
+
+// valueBlock is a declaration of package constants or variables
+type valueBlock struct {
+	Description string `json:",omitempty"`
+	Values      []valueType
+}
+
+type valueType struct {
+	Name        string
+	Type        string
+	Source      string `json:",omitempty"`
+	Value       string `json:",omitempty"`
+	Description string `json:",omitempty"`
+}
+
+// newValueBlockTypes returns the declarations of kind in blocks, nil when there is none
+func newValueBlockTypes(blocks []*ValueBlock, kind string, qualifier types.Qualifier) []valueBlock {
+	var vbs []valueBlock
+	for _, block := range blocks {
+		if block.Kind != kind {
+			continue
+		}
+
+		vb := valueBlock{
+			Description: block.Comment,
+			Values:      make([]valueType, 0, len(block.Values)),
+		}
+		for _, v := range block.Values {
+			vt := valueType{
+				Name:        v.Name(),
+				Type:        v.Type().TypeString(qualifier),
+				Value:       v.Value(),
+				Description: v.Comment,
+			}
+			if v.Type().Invalid() {
+				vt.Type = TypeKindInvalid
+				vt.Source = v.Source
+			}
+			vb.Values = append(vb.Values, vt)
+		}
+		vbs = append(vbs, vb)
+	}
+	return vbs
 }
 
 type fieldType struct {
-	Name string
-	Type interface{}
+	Name       string
+	Type       interface{}
+	Source     string          `json:",omitempty"`
+	Descriptor *typeDescriptor `json:",omitempty"`
 }
 
-// Format json 1
-type JsonFormat1 struct {
-	// Field    jf1field
-	// Function []methodType
-	fields  []*Field
-	methods []*Method
+func newFieldType(f *Field, qualifier types.Qualifier) fieldType {
+	return fieldType{
+		Name:       f.Name(),
+		Type:       typeString(f, qualifier),
+		Source:     invalidSource(f),
+		Descriptor: newTypeDescriptor(f.Type()),
+	}
 }
 
-func (JsonFormat1) Extension() string {
-	return ".json"
+// typeString returns the type string of field, invalid when its type
+// could not be resolved
+func typeString(f *Field, qualifier types.Qualifier) string {
+	if f.Type().Invalid() {
+		return TypeKindInvalid
+	}
+	return f.Type().TypeString(qualifier)
 }
 
-func (this *JsonFormat1) recursiveField(m map[string]string, name string, field *Field, depth int) {
-	if depth >= Depth {
-		return
+// invalidSource returns the type expression of a field whose type could
+// not be resolved, empty otherwise
+func invalidSource(f *Field) string {
+	if !f.Type().Invalid() {
+		return ""
 	}
+	return f.Source
+}
 
-	if field.Struct() == nil {
-		k := field.Name()
-		if name != "" {
-			k = name + "." + k
-		}
+// typeDescriptor is the structured form of a Type
+type typeDescriptor struct {
+	Kind     string
+	Name     string            `json:",omitempty"`
+	PkgPath  string            `json:",omitempty"`
+	Len      int64             `json:",omitempty"`
+	Key      *typeDescriptor   `json:",omitempty"`
+	Elem     *typeDescriptor   `json:",omitempty"`
+	TypeArgs []*typeDescriptor `json:",omitempty"`
+	Params   []*typeDescriptor `json:",omitempty"`
+	Results  []*typeDescriptor `json:",omitempty"`
+}
 
-		v := field.Type().String()
-		m[k] = v
-		return
+func newTypeDescriptor(t Type) *typeDescriptor {
+	if t == nil {
+		return nil
 	}
 
-	str := field.Struct()
+	return &typeDescriptor{
+		Kind:     t.Kind(),
+		Name:     t.Name(),
+		PkgPath:  t.PkgPath(),
+		Len:      t.Len(),
+		Key:      newTypeDescriptor(t.Key()),
+		Elem:     newTypeDescriptor(t.Elem()),
+		TypeArgs: newTypeDescriptors(t.TypeArgs()),
+		Params:   newTypeDescriptors(t.Params()),
+		Results:  newTypeDescriptors(t.Results()),
+	}
+}
 
-	fields := str.Fields()
-	if name != "" {
-		name = name + "." + field.Name()
-	} else {
-		name = field.Name()
+func newTypeDescriptors(typs []Type) []*typeDescriptor {
+	if len(typs) == 0 {
+		return nil
 	}
 
-	for _, f := range fields {
-		this.recursiveField(m, name, f, depth+1)
+	descriptors := make([]*typeDescriptor, 0, len(typs))
+	for _, t := range typs {
+		descriptors = append(descriptors, newTypeDescriptor(t))
 	}
+	return descriptors
 }
 
-func (JsonFormat1) End() string {
-	return "}"
+// newMethodType returns the signature of method shared by json formats
+func newMethodType(m *Method, qualifier types.Qualifier) methodType {
+	jm := methodType{
+		Name:         m.Name(),
+		Description:  m.Comment,
+		Receiver:     m.Receiver(),
+		ReturnsError: m.ReturnsError(),
+		TakesContext: m.TakesContext(),
+		Promoted:     strings.Join(m.Path, "."),
+		Constructs:   m.Constructs(),
+		Variadic:     m.signature.Variadic(),
+		Unexported:   !m.Exported(),
+	}
+
+	params := m.Params()
+	jm.Params = make([]fieldType, 0, len(params))
+	for _, p := range params {
+		jm.Params = append(jm.Params, newFieldType(p, qualifier))
+	}
+
+	if r := m.Return(); r != nil {
+		jm.Return = newFieldType(r, qualifier)
+	}
+
+	results := m.Results()
+	jm.Results = make([]fieldType, 0, len(results))
+	for _, r := range results {
+		jm.Results = append(jm.Results, newFieldType(r, qualifier))
+	}
+
+	return jm
 }
 
-func (f *JsonFormat1) SetMethods(methods []*Method) {
-	f.methods = methods
+// methodSet lists names of methods callable on a value T and on a pointer *T
+type methodSet struct {
+	Value   []string
+	Pointer []string
 }
 
-func (f *JsonFormat1) SetFields(fields []*Field) {
-	f.fields = fields
+func newMethodSet(methods []*Method) methodSet {
+	set := methodSet{
+		Value:   make([]string, 0, len(methods)),
+		Pointer: make([]string, 0, len(methods)),
+	}
+
+	for _, m := range methods {
+		if m.CallableOnValue() {
+			set.Value = append(set.Value, m.Name())
+		}
+		set.Pointer = append(set.Pointer, m.Name())
+	}
+
+	sort.Strings(set.Value)
+	sort.Strings(set.Pointer)
+	return set
 }
 
-func (this *JsonFormat1) Format() string {
-	type str struct {
-		Field    map[string]string
-		Function map[string]methodType
+type fieldMeta struct {
+	Description string            `json:",omitempty"`
+	ReadOnly    bool              `json:",omitempty"`
+	Deprecated  bool              `json:",omitempty"`
+	Promoted    string            `json:",omitempty"`
+	Enum        *Enum             `json:",omitempty"`
+	Tag         string            `json:",omitempty"`
+	Tags        map[string]string `json:",omitempty"`
+	Descriptor  *typeDescriptor   `json:",omitempty"`
+	Constraint  string            `json:",omitempty"`
+	Source      string            `json:",omitempty"`
+	Unexported  bool              `json:",omitempty"`
+}
+
+// newFieldMeta returns the annotations of field, every field has at least
+// its type descriptor
+func newFieldMeta(field *Field) fieldMeta {
+	meta := fieldMeta{
+		Description: field.Description(),
+		ReadOnly:    field.Tag.ReadOnly,
+		Deprecated:  field.Tag.Deprecated,
+		Promoted:    strings.Join(field.Path, "."),
+		Enum:        field.Enum(),
+		Tag:         field.RawTag,
+		Descriptor:  newTypeDescriptor(field.Type()),
+		Constraint:  field.Constraint,
+		Source:      invalidSource(field),
+		Unexported:  !field.Exported(),
 	}
 
-	var (
-		sb strings.Builder
-		mf map[string]string = make(map[string]string)
-		mm                   = make(map[string]methodType)
-	)
+	if tags := field.Tags(); len(tags) > 0 {
+		meta.Tags = tags
+	}
 
-	for _, m := range this.methods {
-		jm := methodType{
-			Name:        m.Name(),
-			Description: m.Comment,
-		}
+	return meta
+}
 
-		params := m.Params()
-		jm.Params = make([]fieldType, 0, len(params))
-		for _, p := range params {
+// jsonReport is the document written by json formats
+type jsonReport struct {
+	Kind         string       `json:",omitempty"`
+	Module       *Module      `json:",omitempty"`
+	Constraint   string       `json:",omitempty"`
+	Test         bool         `json:",omitempty"`
+	TypeParams   []*TypeParam `json:",omitempty"`
+	TypeArgs     []string     `json:",omitempty"`
+	Enum         *Enum        `json:",omitempty"`
+	Signature    *methodType  `json:",omitempty"`
+	Description  string       `json:",omitempty"`
+	Field        map[string]interface{}
+	Function     map[string]methodType
+	MethodSet    methodSet
+	Meta         map[string]fieldMeta  `json:",omitempty"`
+	Conflicts    []*Conflict           `json:",omitempty"`
+	Diagnostics  []*Diagnostic         `json:",omitempty"`
+	Functions    map[string]methodType `json:",omitempty"`
+	Constructors map[string]methodType `json:",omitempty"`
+	Constants    []valueBlock          `json:",omitempty"`
+	Variables    []valueBlock          `json:",omitempty"`
+}
 
-			jm.Params = append(jm.Params, fieldType{
-				Name: p.Name(),
-				Type: p.Type().String(),
-			})
-		}
+// jsonFormat builds the report of json formats, they only differ in how
+// fields are laid out
+type jsonFormat struct {
+	options   FormatOptions
+	str       *Struct
+	qualifier types.Qualifier
+	nesting   *nesting
+}
 
-		if r := m.Return(); r != nil {
-			jm.Return = fieldType{
-				Name: r.Name(),
-				Type: r.Type().String(),
-			}
-		}
+func (jsonFormat) Extension() string {
+	return ".json"
+}
+
+func (f *jsonFormat) SetOptions(options FormatOptions) {
+	f.options = options
+}
+
+func (f *jsonFormat) SetStruct(str *Struct) {
+	f.str = str
+}
 
-		mm[jm.Name] = jm
+// format returns the report of the struct, layout adds each visible field
+// of it to the report
+func (this *jsonFormat) format(layout func(report *jsonReport, field *Field)) string {
+	var sb strings.Builder
+
+	this.nesting = newNesting(this.str.String())
+	this.qualifier = this.options.Qualifier.For(this.str.pkg)
+	methods := this.options.visibleMethods(this.str.Methods())
+
+	report := jsonReport{
+		Kind:         this.str.Kind,
+		Module:       this.str.Module,
+		Constraint:   this.str.Constraint(),
+		Test:         this.str.IsTest(),
+		TypeParams:   this.str.TypeParams,
+		TypeArgs:     this.str.TypeArgs,
+		Enum:         this.str.Enum,
+		Signature:    this.signatureType(),
+		Description:  this.str.Comment,
+		Field:        make(map[string]interface{}),
+		Function:     make(map[string]methodType),
+		MethodSet:    newMethodSet(methods),
+		Meta:         make(map[string]fieldMeta),
+		Conflicts:    this.str.Conflicts(),
+		Diagnostics:  this.str.Diagnostics,
+		Functions:    this.functionTypes(this.str.Functions()),
+		Constructors: this.functionTypes(this.str.Constructors()),
+		Constants:    newValueBlockTypes(this.str.Values(), ValueConst, this.qualifier),
+		Variables:    newValueBlockTypes(this.str.Values(), ValueVar, this.qualifier),
 	}
 
-	for _, field := range this.fields {
-		this.recursiveField(mf, "", field, 1)
+	for _, m := range methods {
+		jm := newMethodType(m, this.qualifier)
+		report.Function[jm.Name] = jm
 	}
 
-	st := str{
-		Field:    mf,
-		Function: mm,
+	for _, field := range this.options.visibleFields(this.str.Fields()) {
+		layout(&report, field)
 	}
 
-	fs, _ := json.Marshal(st)
+	fs, _ := json.Marshal(report)
 	sb.Write(fs)
 
 	return sb.String()
 }
 
-func NewJF1() Format {
-	return &JsonFormat1{}
+// functionTypes returns signatures of functions by name, nil when there is none
+func (this *jsonFormat) functionTypes(functions []*Method) map[string]methodType {
+	if len(functions) == 0 {
+		return nil
+	}
+
+	m := make(map[string]methodType, len(functions))
+	for _, f := range functions {
+		jm := newMethodType(f, this.qualifier)
+		m[jm.Name] = jm
+	}
+	return m
 }
 
-// Format json 1
-type JsonFormat2 struct {
-	fields  []*Field
-	methods []*Method
+func (this *jsonFormat) signatureType() *methodType {
+	signature := this.str.Signature()
+	if signature == nil {
+		return nil
+	}
+
+	jm := newMethodType(signature, this.qualifier)
+	return &jm
 }
 
-func (JsonFormat2) Extension() string {
-	return ".json"
+// Format json 1
+type JsonFormat1 struct {
+	jsonFormat
 }
 
-func (this *JsonFormat2) recursiveField(m map[string]interface{}, field *Field, depth int) {
-	if field.Struct() == nil {
-		k := field.Name()
-		v := field.Type().String()
-		m[k] = v
+func (this *JsonFormat1) recursiveField(m map[string]interface{}, mm map[string]methodType, meta map[string]fieldMeta, name string, field *Field, depth int) {
+	k, ok := field.KeyFrom(this.options.KeySource)
+	if !ok {
 		return
 	}
 
-	if depth >= Depth-1 {
-		return
+	if name != "" {
+		name = name + "." + k
+	} else {
+		name = k
 	}
+
+	meta[name] = newFieldMeta(field)
+
 	str := field.Struct()
-	mm := make(map[string]interface{})
-	fields := str.Fields()
+	if str == nil || !this.options.expands(depth) {
+		v := typeString(field, this.qualifier)
+		m[name] = v
+		return
+	}
+
+	// flattened structs have no object of their own to refer to
+	if _, ok := this.nesting.enter(str, "", false); !ok {
+		m[name] = typeString(field, this.qualifier)
+		return
+	}
+	defer this.nesting.leave(str)
+
+	for _, method := range this.options.visibleMethods(str.Methods()) {
+		jm := newMethodType(method, this.qualifier)
+		mm[name+"."+jm.Name] = jm
+	}
 
+	written := len(m)
+	fields := this.options.visibleFields(str.Fields())
 	for _, f := range fields {
-		this.recursiveField(mm, f, depth+1)
+		this.recursiveField(m, mm, meta, name, f, depth+1)
+	}
+
+	// a struct without visible fields, like time.Location, is kept as its type
+	if len(m) == written {
+		m[name] = typeString(field, this.qualifier)
 	}
-	m[field.Name()] = mm
 }
 
-func (f *JsonFormat2) SetMethods(methods []*Method) {
-	f.methods = methods
+func (JsonFormat1) End() string {
+	return "}"
 }
 
-func (f *JsonFormat2) SetFields(fields []*Field) {
-	f.fields = fields
+func (this *JsonFormat1) Format() string {
+	return this.format(func(report *jsonReport, field *Field) {
+		this.recursiveField(report.Field, report.Function, report.Meta, "", field, 1)
+	})
 }
 
-func (this *JsonFormat2) Format() string {
-	type str struct {
-		Field    map[string]interface{}
-		Function map[string]methodType
-	}
-
-	var (
-		sb strings.Builder
-		mf map[string]interface{} = make(map[string]interface{})
-		mm                        = make(map[string]methodType)
-	)
-
-	for _, m := range this.methods {
-		jm := methodType{
-			Name:        m.Name(),
-			Description: m.Comment,
-			Variadic:    m.signature.Variadic(),
-		}
+func NewJF1() Format {
+	return &JsonFormat1{}
+}
 
-		params := m.Params()
-		jm.Params = make([]fieldType, 0, len(params))
-		for _, p := range params {
-			jm.Params = append(jm.Params, fieldType{
-				Name: p.Name(),
-				Type: p.Type().String(),
-			})
-		}
+// nestedStruct is a struct laid out in a field of json 2, fields and methods
+// are kept apart so that their names never collide. Field is empty for
+// structs without visible fields, like time.Location
+type nestedStruct struct {
+	Field    map[string]interface{}
+	Function map[string]methodType `json:",omitempty"`
+}
 
-		if r := m.Return(); r != nil {
-			jm.Return = fieldType{
-				Name: r.Name(),
-				Type: r.Type().String(),
-			}
-		}
+// Format json 2
+type JsonFormat2 struct {
+	jsonFormat
+}
+
+// recursiveField lays out field in m, ref is the JSON pointer to m
+func (this *JsonFormat2) recursiveField(m map[string]interface{}, meta map[string]fieldMeta, ref string, name string, field *Field, depth int) {
+	k, ok := field.KeyFrom(this.options.KeySource)
+	if !ok {
+		return
+	}
+
+	if name != "" {
+		name = name + "." + k
+	} else {
+		name = k
+	}
+
+	meta[name] = newFieldMeta(field)
 
-		mm[jm.Name] = jm
+	str := field.Struct()
+	if str == nil || !this.options.expands(depth) {
+		v := typeString(field, this.qualifier)
+		m[k] = v
+		return
 	}
 
-	for _, field := range this.fields {
-		this.recursiveField(mf, field, 1)
+	path := pointer(ref, k)
+	if to, ok := this.nesting.enter(str, path, this.options.References); !ok {
+		if this.options.References {
+			m[k] = reference{Ref: to}
+		} else {
+			m[k] = typeString(field, this.qualifier)
+		}
+		return
 	}
+	defer this.nesting.leave(str)
+
+	nested := nestedStruct{Field: make(map[string]interface{})}
+	fields := this.options.visibleFields(str.Fields())
 
-	st := str{
-		Field:    mf,
-		Function: mm,
+	for _, f := range fields {
+		this.recursiveField(nested.Field, meta, pointer(path, "Field"), name, f, depth+1)
 	}
 
-	fs, _ := json.Marshal(st)
-	sb.Write(fs)
+	if methods := this.options.visibleMethods(str.Methods()); len(methods) > 0 {
+		nested.Function = make(map[string]methodType, len(methods))
+		for _, method := range methods {
+			jm := newMethodType(method, this.qualifier)
+			nested.Function[jm.Name] = jm
+		}
+	}
+	m[k] = nested
+}
 
-	return sb.String()
+func (this *JsonFormat2) Format() string {
+	return this.format(func(report *jsonReport, field *Field) {
+		this.recursiveField(report.Field, report.Meta, pointer(RootReference, "Field"), "", field, 1)
+	})
 }
 
 func NewJF2() Format {
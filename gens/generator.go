@@ -26,9 +26,8 @@ func NewInformationGenerator(str *Struct, format Format) *InformationGenerator {
 }
 
 func (g *InformationGenerator) Generate(ctx context.Context) error {
-	g.format.SetFields(g.str.Fields())
-	g.format.SetMethods(g.str.Methods())
-	g.printf(g.format.Format())
+	g.format.SetStruct(g.str)
+	g.printf("%s", g.format.Format())
 
 	return nil
 }
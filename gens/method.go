@@ -1,17 +1,120 @@
 package gens
 
-import "go/types"
+import (
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const (
+	ReceiverValue   = "value"
+	ReceiverPointer = "pointer"
+)
+
+var (
+	errorType = types.Universe.Lookup("error").Type()
+)
 
 type Method struct {
-	Comment   string
+	Comment string
+	// Path lists the embedded fields a promoted method is selected through
+	Path      []string
 	_func     *types.Func
 	signature *types.Signature
+	onValue   bool
+	sources   sources
+}
+
+// newMethods returns declared methods of typ followed by methods promoted
+// from its embedded fields
+func newMethods(typ *types.Named, docs docs, sources sources) []*Method {
+	var (
+		methods  = []*Method{}
+		valueSet = types.NewMethodSet(typ)
+	)
+
+	for loop := 0; loop < typ.NumMethods(); loop++ {
+		f := typ.Method(loop)
+		sig, ok := f.Type().Underlying().(*types.Signature)
+		if !ok {
+			continue
+		}
+
+		methods = append(methods, &Method{
+			Comment:   docs[f.Pos()],
+			_func:     f,
+			signature: sig,
+			onValue:   valueSet.Lookup(f.Pkg(), f.Name()) != nil,
+			sources:   sources,
+		})
+	}
+
+	for _, sel := range typeutil.IntuitiveMethodSet(typ, nil) {
+		index := sel.Index()
+		if len(index) < 2 {
+			continue
+		}
+
+		f, ok := sel.Obj().(*types.Func)
+		if !ok {
+			continue
+		}
+
+		sig, ok := f.Type().Underlying().(*types.Signature)
+		if !ok {
+			continue
+		}
+
+		methods = append(methods, &Method{
+			Comment:   docs[f.Pos()],
+			Path:      embeddingPath(typ, index[:len(index)-1]),
+			_func:     f,
+			signature: sig,
+			onValue:   valueSet.Lookup(f.Pkg(), f.Name()) != nil,
+			sources:   sources,
+		})
+	}
+
+	return methods
+}
+
+// newFunctions returns exported package-level functions of pkg in
+// declaration order
+func newFunctions(pkg *types.Package, docs docs, sources sources) []*Method {
+	functions := []*Method{}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		f, ok := scope.Lookup(name).(*types.Func)
+		if !ok || !f.Exported() {
+			continue
+		}
+
+		functions = append(functions, &Method{
+			Comment:   docs[f.Pos()],
+			_func:     f,
+			signature: f.Type().(*types.Signature),
+			onValue:   true,
+			sources:   sources,
+		})
+	}
+
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i]._func.Pos() < functions[j]._func.Pos()
+	})
+	return functions
 }
 
 func (m *Method) Name() string {
 	return m._func.Name()
 }
 
+// Exported reports whether method is exported
+func (m *Method) Exported() bool {
+	return m._func.Exported()
+}
+
 func (m *Method) Params() []*Field {
 	var (
 		params = []*Field{}
@@ -21,7 +124,9 @@ func (m *Method) Params() []*Field {
 	for loop := 0; loop < paramsCount; loop++ {
 		v := m.signature.Params().At(loop)
 		params = append(params, &Field{
-			_var: v,
+			Source:  m.sources[v.Pos()],
+			_var:    v,
+			sources: m.sources,
 		})
 	}
 	return params
@@ -31,9 +136,112 @@ func (m *Method) Return() *Field {
 	if m.signature.Results().Len() > 0 {
 		v := m.signature.Results().At(0)
 		return &Field{
-			_var: v,
+			Source:  m.sources[v.Pos()],
+			_var:    v,
+			sources: m.sources,
 		}
 	}
 
 	return nil
 }
+
+// Receiver returns the kind of receiver method is declared with, empty
+// for functions and interface methods
+func (m *Method) Receiver() string {
+	recv := m.signature.Recv()
+	if recv == nil || types.IsInterface(recv.Type()) {
+		return ""
+	}
+
+	if _, ok := recv.Type().(*types.Pointer); ok {
+		return ReceiverPointer
+	}
+	return ReceiverValue
+}
+
+// CallableOnValue reports whether method belongs to the method set of T,
+// otherwise it can only be called on *T
+func (m *Method) CallableOnValue() bool {
+	return m.onValue
+}
+
+// Results returns all results of method, named results keep their name
+func (m *Method) Results() []*Field {
+	var (
+		results = []*Field{}
+	)
+
+	resultsCount := m.signature.Results().Len()
+	for loop := 0; loop < resultsCount; loop++ {
+		v := m.signature.Results().At(loop)
+		results = append(results, &Field{
+			Source:  m.sources[v.Pos()],
+			_var:    v,
+			sources: m.sources,
+		})
+	}
+	return results
+}
+
+// ReturnsError reports whether the last result of method is an error
+func (m *Method) ReturnsError() bool {
+	results := m.signature.Results()
+	if results.Len() == 0 {
+		return false
+	}
+
+	return types.Identical(results.At(results.Len()-1).Type(), errorType)
+}
+
+// Constructs returns the name of the struct a package-level function
+// builds, a function returning T or *T optionally followed by an error
+// where T is a struct declared in its package. It is empty otherwise
+func (m *Method) Constructs() string {
+	if named := m.constructs(); named != nil {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
+func (m *Method) constructs() *types.Named {
+	if m.signature.Recv() != nil {
+		return nil
+	}
+
+	results := m.signature.Results()
+	switch {
+	case results.Len() == 1:
+	case results.Len() == 2 && types.Identical(results.At(1).Type(), errorType):
+	default:
+		return nil
+	}
+
+	typ := results.At(0).Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() != m._func.Pkg() {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return named
+}
+
+// TakesContext reports whether the first parameter of method is a context.Context
+func (m *Method) TakesContext() bool {
+	params := m.signature.Params()
+	if params.Len() == 0 {
+		return false
+	}
+
+	named, ok := params.At(0).Type().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
@@ -0,0 +1,113 @@
+package gens
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Module is the go module a struct is declared in
+type Module struct {
+	Path string
+	// Version is empty for main and workspace modules
+	Version string `json:",omitempty"`
+}
+
+func newModule(m *packages.Module) *Module {
+	if m == nil {
+		return nil
+	}
+
+	version := m.Version
+	if m.Replace != nil && m.Replace.Version != "" {
+		version = m.Replace.Version
+	}
+
+	return &Module{
+		Path:    m.Path,
+		Version: version,
+	}
+}
+
+// recursivePattern is the suffix of patterns matching every package below a directory
+const recursivePattern = "/..."
+
+// isLocalPattern reports whether pattern names directories rather than import paths
+func isLocalPattern(pattern string) bool {
+	if filepath.IsAbs(pattern) || pattern == "." || pattern == ".." ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../") {
+		return true
+	}
+
+	info, err := os.Stat(strings.TrimSuffix(pattern, recursivePattern))
+	return err == nil && info.IsDir()
+}
+
+// patternRoot returns the directory a local pattern is rooted at
+func patternRoot(pattern string) string {
+	if pattern == "..." {
+		return "."
+	}
+	return strings.TrimSuffix(pattern, recursivePattern)
+}
+
+// loadRoot returns the directory packages of dir are loaded from, the
+// workspace root when a go.work is found above dir, the module root
+// otherwise. Loading from there lets nested modules resolve their own go.mod
+func loadRoot(dir string) (root string, workspace bool) {
+	if os.Getenv("GOWORK") != "off" {
+		if root, ok := findUp(dir, "go.work"); ok {
+			return root, true
+		}
+	}
+
+	if root, ok := findUp(dir, "go.mod"); ok {
+		return root, false
+	}
+	return dir, false
+}
+
+// findUp returns the closest directory from dir upward containing name
+func findUp(dir, name string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// nestedModules returns roots of modules declared below dir, a pattern
+// ending with /... stops at their go.mod so they are loaded on their own
+func nestedModules(dir string) []string {
+	roots := make([]string, 0)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return roots
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor" {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+			roots = append(roots, path)
+		}
+		roots = append(roots, nestedModules(path)...)
+	}
+
+	return roots
+}
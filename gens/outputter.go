@@ -19,6 +19,9 @@ type OutputStreamProvider interface {
 	GetStructWriter(context.Context, *Struct, string) (io.Writer, error, Cleanup)
 }
 
+// packageSuffix ends the file names of package infos
+const packageSuffix = ".package"
+
 type FileOutputStreamProvider struct {
 	Config config.Config
 }
@@ -26,30 +29,12 @@ type FileOutputStreamProvider struct {
 func (o *FileOutputStreamProvider) GetStructWriter(ctx context.Context, str *Struct, extension string) (io.Writer, error, Cleanup) {
 	log := zerolog.Ctx(ctx).With().Str(logging.LogKeyInterface, str.Name).Logger()
 
-	var path string
-
-	caseName := str.Name
-	if o.Config.Case == "underscore" || o.Config.Case == "snake" {
-		caseName = o.underscoreCaseName(caseName)
+	path, err := o.Path(str, extension)
+	if err != nil {
+		return nil, err, func() error { return nil }
 	}
-
-	if o.Config.KeepTree {
-		absOriginalDir, err := filepath.Abs(o.Config.Directory)
-		if err != nil {
-			return nil, err, func() error { return nil }
-		}
-		relativePath := strings.TrimPrefix(
-			filepath.Join(filepath.Dir(str.FileName), o.filename(caseName, extension)),
-			absOriginalDir)
-		path = filepath.Join(o.Config.Output, relativePath)
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return nil, err, func() error { return nil }
-		}
-	} else {
-		path = filepath.Join(o.Config.Output, o.filename(caseName, extension))
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return nil, err, func() error { return nil }
-		}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err, func() error { return nil }
 	}
 
 	log = log.With().Str(logging.LogKeyPath, path).Logger()
@@ -65,6 +50,67 @@ func (o *FileOutputStreamProvider) GetStructWriter(ctx context.Context, str *Str
 	}
 }
 
+// Path returns the path info of str is written to
+func (o *FileOutputStreamProvider) Path(str *Struct, extension string) (string, error) {
+	caseName := o.safeName(str.Name)
+	if o.Config.Case == "underscore" || o.Config.Case == "snake" {
+		caseName = o.underscoreCaseName(caseName)
+	}
+	if str.Kind == KindPackage {
+		// no type name makes a dot, a package and a type can share a name
+		caseName += packageSuffix
+	}
+
+	if !o.Config.KeepTree {
+		return filepath.Join(o.Config.Output, o.filename(caseName, extension)), nil
+	}
+
+	absOriginalDir, err := o.baseDir(str.FileName)
+	if err != nil {
+		return "", err
+	}
+	relativeDir, ok := relativeTo(absOriginalDir, filepath.Dir(str.FileName))
+	if !ok {
+		relativeDir = filepath.Dir(str.FileName)
+	}
+	return filepath.Join(o.Config.Output, relativeDir, o.filename(caseName, extension)), nil
+}
+
+// baseDir returns the longest searched directory or pattern root fileName is in
+func (o *FileOutputStreamProvider) baseDir(fileName string) (string, error) {
+	roots := append([]string{}, o.Config.Directories...)
+	for _, pattern := range o.Config.Patterns {
+		if isLocalPattern(pattern) {
+			roots = append(roots, patternRoot(pattern))
+		}
+	}
+
+	base := ""
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := relativeTo(abs, fileName); ok && len(abs) > len(base) {
+			base = abs
+		}
+	}
+
+	if base == "" {
+		return filepath.Abs(".")
+	}
+	return base, nil
+}
+
+// relativeTo returns path relative to dir, ok is false when path is not in dir
+func relativeTo(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
 func (o *FileOutputStreamProvider) filename(name string, extension string) string {
 	if o.Config.FileName != "" {
 		return o.Config.FileName
@@ -76,5 +122,13 @@ func (o *FileOutputStreamProvider) underscoreCaseName(caseName string) string {
 	rxp1 := regexp.MustCompile("(.)([A-Z][a-z]+)")
 	s1 := rxp1.ReplaceAllString(caseName, "${1}_${2}")
 	rxp2 := regexp.MustCompile("([a-z0-9])([A-Z])")
-	return strings.ToLower(rxp2.ReplaceAllString(s1, "${1}_${2}"))
+	rxp3 := regexp.MustCompile("_+")
+	return strings.ToLower(rxp3.ReplaceAllString(rxp2.ReplaceAllString(s1, "${1}_${2}"), "_"))
+}
+
+// safeName replaces characters of instantiated names like Page[models.Campaign]
+// which are not usable in file names
+func (o *FileOutputStreamProvider) safeName(name string) string {
+	rxp := regexp.MustCompile("[^A-Za-z0-9_]+")
+	return strings.Trim(rxp.ReplaceAllString(name, "_"), "_")
 }
@@ -0,0 +1,84 @@
+package gens
+
+import (
+	"testing"
+
+	"gitlab.id.vin/nam.nguyen10/typeinfo/config"
+)
+
+func TestPathKeepTree(t *testing.T) {
+	tests := []struct {
+		name     string
+		dirs     []string
+		fileName string
+		want     string
+	}{
+		{
+			name:     "file in searched directory",
+			dirs:     []string{"/repo/facts"},
+			fileName: "/repo/facts/fact.go",
+			want:     "infos/Fact.json",
+		},
+		{
+			name:     "file in nested directory",
+			dirs:     []string{"/repo/facts"},
+			fileName: "/repo/facts/campaign/fact.go",
+			want:     "infos/campaign/Fact.json",
+		},
+		{
+			name:     "sibling sharing a name prefix",
+			dirs:     []string{"/repo/facts", "/repo"},
+			fileName: "/repo/facts2/fact.go",
+			want:     "infos/facts2/Fact.json",
+		},
+		{
+			name:     "longest directory wins",
+			dirs:     []string{"/repo", "/repo/facts"},
+			fileName: "/repo/facts/fact.go",
+			want:     "infos/Fact.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &FileOutputStreamProvider{Config: config.Config{
+				Output:      "infos",
+				KeepTree:    true,
+				Directories: tt.dirs,
+			}}
+
+			got, err := o.Path(&Struct{Name: "Fact", FileName: tt.fileName}, ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathPackage(t *testing.T) {
+	tests := []struct {
+		name string
+		str  *Struct
+		want string
+	}{
+		{name: "package", str: &Struct{Name: "examples/jf", Kind: KindPackage}, want: "infos/examples_jf.package.json"},
+		{name: "struct", str: &Struct{Name: "Condition", Kind: KindStruct}, want: "infos/condition.json"},
+		{name: "package named like a struct", str: &Struct{Name: "condition", Kind: KindPackage}, want: "infos/condition.package.json"},
+	}
+
+	o := &FileOutputStreamProvider{Config: config.Config{Output: "infos", Case: "snake"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := o.Path(tt.str, ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
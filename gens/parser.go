@@ -2,11 +2,13 @@ package gens
 
 import (
 	"context"
-	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
-	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -20,7 +22,9 @@ type parserEntry struct {
 	syntax     *ast.File
 	interfaces []string
 	structs    []string
-	comments   []*ast.CommentGroup
+	enums      []string
+	// docs are doc comments of the file as written
+	docs docs
 }
 
 type Parser struct {
@@ -28,103 +32,350 @@ type Parser struct {
 	entriesByFileName map[string]*parserEntry
 	parserPackages    []*types.Package
 	conf              packages.Config
+	docs              docs
+	constraints       constraints
+	// patterns are queued package patterns, queued indexes them
+	patterns []string
+	queued   map[string]int
+	modules  map[string]*Module
+	// diagnostics are errors of loaded packages, diagnosed lists them in order
+	diagnostics map[*types.Package][]*Diagnostic
+	diagnosed   []*types.Package
+	mode        string
+	build       BuildConfig
+	sources     sources
+	// valueFilter selects package constants and variables by name, nil selects all
+	valueFilter *regexp.Regexp
+	unexported  bool
+	// docRenderer renders doc comments, nil keeps them as written
+	docRenderer *DocRenderer
+	// linked are files of the types doc links point to by package path and name
+	linked map[string]string
+	// qualifier names packages in type parameters and type arguments
+	qualifier Qualifier
 }
 
-func NewParser(buildTags []string) *Parser {
+// docs indexes doc comments of declared types, struct fields and methods by position
+type docs map[token.Pos]string
+
+// sources indexes source expressions of struct field, parameter and result
+// types by the position of their variable
+type sources map[token.Pos]string
+
+func NewParser(buildTags []string, goos, goarch string) *Parser {
 	var conf packages.Config
-	conf.Mode = packages.NeedFiles | packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax
+	conf.Mode = packages.NeedFiles | packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedModule
+	// shares file set between loads so positions are unique across packages
+	conf.Fset = token.NewFileSet()
 	if len(buildTags) > 0 {
 		conf.BuildFlags = []string{"-tags", strings.Join(buildTags, ",")}
 	}
+	if goos != "" || goarch != "" {
+		conf.Env = os.Environ()
+		if goos != "" {
+			conf.Env = append(conf.Env, "GOOS="+goos)
+		}
+		if goarch != "" {
+			conf.Env = append(conf.Env, "GOARCH="+goarch)
+		}
+	}
 	return &Parser{
+		build:             BuildConfig{GOOS: goos, GOARCH: goarch, Tags: buildTags},
 		parserPackages:    make([]*types.Package, 0),
 		entriesByFileName: map[string]*parserEntry{},
 		conf:              conf,
+		docs:              docs{},
+		constraints:       constraints{},
+		queued:            map[string]int{},
+		modules:           map[string]*Module{},
+		diagnostics:       map[*types.Package][]*Diagnostic{},
+		sources:           sources{},
+	}
+}
+
+// SetMode chooses how packages are loaded, ModePackages or ModeSyntax
+func (p *Parser) SetMode(mode string) {
+	p.mode = mode
+}
+
+// SetValueFilter selects the package constants and variables reported by
+// name, nil selects all of them
+func (p *Parser) SetValueFilter(filter *regexp.Regexp) {
+	p.valueFilter = filter
+}
+
+// SetUnexported includes unexported fields and methods in parsed structs
+func (p *Parser) SetUnexported(include bool) {
+	p.unexported = include
+}
+
+// SetDocRenderer renders doc comments of loaded packages with r once
+// RenderDocs is called, nil keeps them as written
+func (p *Parser) SetDocRenderer(r *DocRenderer) {
+	p.docRenderer = r
+}
+
+// SetQualifier names packages in type parameters and type arguments the way
+// formats name them in field types
+func (p *Parser) SetQualifier(q Qualifier) {
+	p.qualifier = q
+}
+
+// SetTests loads test variants of packages, including files and external
+// packages of _test.go files
+func (p *Parser) SetTests(include bool) {
+	p.conf.Tests = include
+}
+
+// SetOverlay type-checks contents of overlay in place of the files on disk
+// at their paths, files do not need to exist
+func (p *Parser) SetOverlay(overlay map[string][]byte) error {
+	if len(overlay) == 0 {
+		return nil
 	}
+
+	p.conf.Overlay = make(map[string][]byte, len(overlay))
+	for path, contents := range overlay {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		p.conf.Overlay[abs] = contents
+	}
+	return nil
 }
 
+// Parse queues the package of the go file at path, queued packages are
+// loaded together by Load
 func (p *Parser) Parse(ctx context.Context, path string) error {
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
 
-	dir := filepath.Dir(path)
+	p.queue(ctx, filepath.Dir(path))
+	return nil
+}
+
+// AddPattern queues packages matched by a go package pattern such as
+// ./..., a directory or an import path. Recursive patterns also match
+// packages of modules nested below their directory
+func (p *Parser) AddPattern(ctx context.Context, pattern string) error {
+	if !isLocalPattern(pattern) {
+		p.queue(ctx, pattern)
+		return nil
+	}
 
-	files, err := ioutil.ReadDir(dir)
+	root, err := filepath.Abs(patternRoot(pattern))
 	if err != nil {
 		return err
 	}
 
-	for _, fi := range files {
-		log := zerolog.Ctx(ctx).With().
-			Str(logging.LogKeyDir, dir).
-			Str(logging.LogKeyFile, fi.Name()).
-			Logger()
-		ctx = log.WithContext(ctx)
+	if !strings.HasSuffix(pattern, "...") {
+		p.queue(ctx, root)
+		return nil
+	}
 
-		if filepath.Ext(fi.Name()) != ".go" || strings.HasSuffix(fi.Name(), "_test.go") {
-			continue
-		}
+	p.queue(ctx, root+recursivePattern)
+	if _, workspace := loadRoot(root); workspace {
+		// workspace modules are matched from the go.work directory
+		return nil
+	}
 
-		log.Debug().Msgf("parsing")
+	for _, module := range nestedModules(root) {
+		p.queue(ctx, module+recursivePattern)
+	}
+	return nil
+}
 
-		fname := fi.Name()
-		fpath := filepath.Join(dir, fname)
-		if _, ok := p.entriesByFileName[fpath]; ok {
-			continue
-		}
+func (p *Parser) queue(ctx context.Context, pattern string) {
+	if _, ok := p.queued[pattern]; ok {
+		return
+	}
 
-		pkgs, err := packages.Load(&p.conf, "file="+fpath)
-		if err != nil {
-			return err
-		}
-		if len(pkgs) == 0 {
+	zerolog.Ctx(ctx).Debug().
+		Str(logging.LogKeyDir, pattern).
+		Msgf("queued package")
+
+	p.queued[pattern] = len(p.patterns)
+	p.patterns = append(p.patterns, pattern)
+}
+
+// loadPackages type-checks queued packages, with go/packages or from
+// syntax alone depending on mode, and adds entries of their files in queued order.
+// Errors of packages are kept as diagnostics, whatever type-checks is still
+// used. The first load error is returned after the others are added
+func (p *Parser) loadPackages() error {
+	var (
+		loaded []*packages.Package
+		first  error
+	)
+
+	if p.mode == ModeSyntax {
+		loaded, first = newSyntaxLoader(p.conf, p.build).Load(p.patterns...)
+	} else {
+		loaded, first = p.loadModules()
+	}
+
+	sort.SliceStable(loaded, func(i, j int) bool {
+		return p.rank(loaded[i]) < p.rank(loaded[j])
+	})
+
+	for _, pkg := range loaded {
+		if strings.HasSuffix(pkg.ID, ".test") {
+			// generated main package of the test binary
 			continue
 		}
-		if len(pkgs) > 1 {
-			names := make([]string, len(pkgs))
-			for i, p := range pkgs {
-				names[i] = p.Name
-			}
-			panic(fmt.Sprintf("file %s resolves to multiple packages: %s", fpath, strings.Join(names, ", ")))
+		if pkg.Types == nil {
+			continue
 		}
 
-		pkg := pkgs[0]
 		if len(pkg.Errors) > 0 {
-			return pkg.Errors[0]
+			p.diagnosed = append(p.diagnosed, pkg.Types)
 		}
-		if len(pkg.GoFiles) == 0 {
-			continue
+		for _, err := range pkg.Errors {
+			p.diagnostics[pkg.Types] = append(p.diagnostics[pkg.Types], newDiagnostic(err))
+		}
+
+		if module := newModule(pkg.Module); module != nil {
+			p.modules[pkg.PkgPath] = module
 		}
 
-		for idx, f := range pkg.GoFiles {
-			if _, ok := p.entriesByFileName[f]; ok {
+		// files failing to parse may have no syntax
+		syntax := make(map[string]*ast.File, len(pkg.Syntax))
+		for _, file := range pkg.Syntax {
+			syntax[p.conf.Fset.File(file.Pos()).Name()] = file
+		}
+
+		for _, f := range pkg.GoFiles {
+			if _, ok := p.entriesByFileName[f]; ok || syntax[f] == nil {
 				continue
 			}
 
 			entry := parserEntry{
 				fileName: f,
 				pkg:      pkg,
-				syntax:   pkg.Syntax[idx],
+				syntax:   syntax[f],
 			}
 			p.entries = append(p.entries, &entry)
 			p.entriesByFileName[f] = &entry
 		}
 	}
 
-	return nil
+	return first
+}
+
+// loadModules calls packages.Load once per module or workspace of the
+// queued patterns
+func (p *Parser) loadModules() ([]*packages.Package, error) {
+	var (
+		roots    []string
+		patterns = map[string][]string{}
+		loaded   []*packages.Package
+		first    error
+	)
+
+	for _, pattern := range p.patterns {
+		root := ""
+		if filepath.IsAbs(pattern) {
+			root, _ = loadRoot(patternRoot(pattern))
+		}
+		if _, ok := patterns[root]; !ok {
+			roots = append(roots, root)
+		}
+		patterns[root] = append(patterns[root], pattern)
+	}
+
+	for _, root := range roots {
+		conf := p.conf
+		conf.Dir = root
+
+		pkgs, err := packages.Load(&conf, patterns[root]...)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		loaded = append(loaded, pkgs...)
+	}
+
+	return loaded, first
+}
+
+// rank returns the index of the first queued pattern matching pkg
+func (p *Parser) rank(pkg *packages.Package) int {
+	dir := ""
+	if len(pkg.GoFiles) > 0 {
+		dir = filepath.Dir(pkg.GoFiles[0])
+	}
+
+	for index, pattern := range p.patterns {
+		if pattern == dir || pattern == pkg.PkgPath {
+			return index
+		}
+
+		if !strings.HasSuffix(pattern, recursivePattern) {
+			continue
+		}
+
+		root, matched, separator := patternRoot(pattern), pkg.PkgPath, "/"
+		if filepath.IsAbs(pattern) {
+			matched, separator = dir, string(filepath.Separator)
+		}
+		if matched == root || strings.HasPrefix(matched, root+separator) {
+			return index
+		}
+	}
+
+	return len(p.patterns)
+}
+
+// Diagnostics returns errors of pkg located in fileName or without position
+func (p *Parser) Diagnostics(pkg *types.Package, fileName string) []*Diagnostic {
+	diagnostics := make([]*Diagnostic, 0)
+	for _, d := range p.diagnostics[pkg] {
+		if d.in(fileName) {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// AllDiagnostics returns errors of every loaded package
+func (p *Parser) AllDiagnostics() []*Diagnostic {
+	diagnostics := make([]*Diagnostic, 0)
+	for _, pkg := range p.diagnosed {
+		diagnostics = append(diagnostics, p.diagnostics[pkg]...)
+	}
+	return diagnostics
+}
+
+// Module returns the module pkg is loaded from, nil when unknown
+func (p *Parser) Module(pkg *types.Package) *Module {
+	if pkg == nil {
+		return nil
+	}
+	return p.modules[pkg.Path()]
 }
 
 type NodeVisitor struct {
 	declaredInterfaces []string
 	declaredStructs    []string
-	comments           []*ast.CommentGroup
+	declaredEnums      []string
+	docs               docs
+	// constraint is the build constraint of the visited file
+	constraint  string
+	constraints constraints
+	sources     sources
 }
 
 func NewNodeVisitor() *NodeVisitor {
 	return &NodeVisitor{
 		declaredInterfaces: make([]string, 0),
+		docs:               docs{},
+		constraints:        constraints{},
+		sources:            sources{},
 	}
 }
 
@@ -136,47 +387,273 @@ func (n *NodeVisitor) DeclaredStructs() []string {
 	return n.declaredStructs
 }
 
+// DeclaredEnums returns named types which may have typed constants
+func (n *NodeVisitor) DeclaredEnums() []string {
+	return n.declaredEnums
+}
+
 func (nv *NodeVisitor) Visit(node ast.Node) ast.Visitor {
 	switch n := node.(type) {
+	case *ast.GenDecl:
+		for _, spec := range n.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				nv.visitTypeDoc(n, spec)
+			case *ast.ValueSpec:
+				nv.visitValueDoc(n, spec)
+				nv.visitValueSource(spec)
+			}
+		}
+	case *ast.File:
+		if n.Doc != nil {
+			nv.docs[n.Name.Pos()] = n.Doc.Text()
+		}
+	case *ast.FuncDecl:
+		// only the comment group directly above documents a function,
+		// detached comments separated by a blank line are not its doc
+		if n.Doc != nil {
+			nv.docs[n.Name.Pos()] = n.Doc.Text()
+		}
 	case *ast.TypeSpec:
 		switch n.Type.(type) {
 		case *ast.InterfaceType, *ast.FuncType:
 			nv.declaredInterfaces = append(nv.declaredInterfaces, n.Name.Name)
 		case *ast.StructType:
 			nv.declaredStructs = append(nv.declaredStructs, n.Name.Name)
+		case *ast.Ident, *ast.SelectorExpr:
+			if !n.Assign.IsValid() {
+				nv.declaredEnums = append(nv.declaredEnums, n.Name.Name)
+			}
 		}
-	case *ast.CommentGroup:
-		nv.comments = append(nv.comments, n)
+	case *ast.Field:
+		nv.visitSource(n)
 	}
 	return nv
 }
 
+// visitSource records the type expression of a struct field, a parameter or
+// a result at the positions go/types declares their variables at
+func (nv *NodeVisitor) visitSource(field *ast.Field) {
+	source := types.ExprString(field.Type)
+
+	if len(field.Names) > 0 {
+		for _, name := range field.Names {
+			nv.sources[name.Pos()] = source
+		}
+		return
+	}
+
+	nv.sources[field.Type.Pos()] = source
+	if ident := embeddedFieldIdent(field.Type); ident != nil {
+		nv.sources[ident.Pos()] = source
+	}
+}
+
+// visitTypeDoc records doc comments of a declared type and of its fields or
+// interface methods
+func (nv *NodeVisitor) visitTypeDoc(decl *ast.GenDecl, spec *ast.TypeSpec) {
+	nv.visitConstraint(spec)
+
+	doc := spec.Doc
+	if doc == nil && len(decl.Specs) == 1 {
+		doc = decl.Doc
+	}
+	if doc != nil {
+		nv.docs[spec.Name.Pos()] = doc.Text()
+	}
+
+	var fields *ast.FieldList
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	}
+	if fields == nil {
+		return
+	}
+
+	for _, field := range fields.List {
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		if doc == nil {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			if ident := embeddedFieldIdent(field.Type); ident != nil {
+				nv.docs[ident.Pos()] = doc.Text()
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			nv.docs[name.Pos()] = doc.Text()
+		}
+	}
+}
+
+// visitConstraint records the file constraint of a declared type and of its
+// struct fields
+func (nv *NodeVisitor) visitConstraint(spec *ast.TypeSpec) {
+	if nv.constraint == "" {
+		return
+	}
+
+	nv.constraints[spec.Name.Pos()] = nv.constraint
+
+	t, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+
+	for _, field := range t.Fields.List {
+		if len(field.Names) == 0 {
+			if ident := embeddedFieldIdent(field.Type); ident != nil {
+				nv.constraints[ident.Pos()] = nv.constraint
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			nv.constraints[name.Pos()] = nv.constraint
+		}
+	}
+}
+
+// visitValueDoc records doc comments of declared constants and variables
+func (nv *NodeVisitor) visitValueDoc(decl *ast.GenDecl, spec *ast.ValueSpec) {
+	doc := spec.Doc
+	if doc == nil {
+		doc = spec.Comment
+	}
+	if doc == nil && len(decl.Specs) == 1 {
+		doc = decl.Doc
+	}
+	if doc == nil {
+		return
+	}
+
+	for _, name := range spec.Names {
+		nv.docs[name.Pos()] = doc.Text()
+	}
+}
+
+// visitValueSource records the type expression of variables declared with one
+func (nv *NodeVisitor) visitValueSource(spec *ast.ValueSpec) {
+	if spec.Type == nil {
+		return
+	}
+
+	source := types.ExprString(spec.Type)
+	for _, name := range spec.Names {
+		nv.sources[name.Pos()] = source
+	}
+}
+
+// embeddedFieldIdent returns the identifier go/types positions an embedded field at
+func embeddedFieldIdent(e ast.Expr) *ast.Ident {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.StarExpr:
+		return embeddedFieldIdent(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
 func (p *Parser) Load() error {
+	err := p.loadPackages()
+
 	for _, entry := range p.entries {
 		nv := NewNodeVisitor()
+		nv.constraint = fileConstraint(entry.syntax, entry.fileName)
 		ast.Walk(nv, entry.syntax)
 
 		entry.interfaces = nv.DeclaredInterfaces()
 		entry.structs = nv.DeclaredStructs()
-		entry.comments = nv.comments
+		entry.enums = nv.DeclaredEnums()
+		entry.docs = nv.docs
+		for pos, doc := range nv.docs {
+			p.docs[pos] = doc
+		}
+		for pos, constraint := range nv.constraints {
+			p.constraints[pos] = constraint
+		}
+		for pos, source := range nv.sources {
+			p.sources[pos] = source
+		}
 	}
-	return nil
+
+	return err
+}
+
+// RenderDocs renders doc comments of loaded packages with the doc renderer,
+// doc links resolve to the infos of structs, those emitted by the run.
+// Structs built before keep the comments they were built with
+func (p *Parser) RenderDocs(structs []*Struct) {
+	if p.docRenderer == nil {
+		return
+	}
+
+	p.linked = map[string]string{}
+	for _, str := range structs {
+		if str.Kind != KindPackage {
+			p.linked[str.pkg.Path()+"."+str.Name] = str.FileName
+		}
+	}
+
+	for _, entry := range p.entries {
+		for pos, doc := range entry.docs {
+			p.docs[pos] = p.renderDoc(doc, entry.pkg.Types, entry.fileName)
+		}
+	}
+}
+
+// renderDoc renders doc, a comment of fileName in pkg, with the doc
+// renderer of the parser
+func (p *Parser) renderDoc(doc string, pkg *types.Package, fileName string) string {
+	if p.docRenderer == nil || doc == "" {
+		return doc
+	}
+
+	return p.docRenderer.render(doc, pkg, func(pkgPath, name string) (string, bool) {
+		toFile, ok := p.linked[pkgPath+"."+name]
+		if !ok || p.docRenderer.Link == nil {
+			return "", false
+		}
+		return p.docRenderer.Link(fileName, name, toFile)
+	})
 }
 
 func (p *Parser) Structs() []*Struct {
 	structs := make([]*Struct, 0)
 	for _, entry := range p.entries {
 		declaredStructs := entry.structs
-		structs = p.packageStructs(entry.pkg.Types, entry.fileName, declaredStructs, structs, entry.comments)
+		structs = p.packageStructs(entry.pkg.Types, entry.fileName, declaredStructs, structs)
 	}
 
 	return structs
 }
 
-func (p *Parser) packageStructs(pkg *types.Package, fileName string, declaredStructs []string, structs []*Struct, comments []*ast.CommentGroup) []*Struct {
+// Interfaces returns declared interfaces and named function types
+func (p *Parser) Interfaces() []*Struct {
+	interfaces := make([]*Struct, 0)
+	for _, entry := range p.entries {
+		interfaces = p.packageInterfaces(entry.pkg.Types, entry.fileName, entry.interfaces, interfaces)
+	}
+
+	return interfaces
+}
+
+func (p *Parser) packageInterfaces(pkg *types.Package, fileName string, declaredInterfaces []string, interfaces []*Struct) []*Struct {
 	scope := pkg.Scope()
 
-	for _, name := range declaredStructs {
+	for _, name := range declaredInterfaces {
 		obj := scope.Lookup(name)
 
 		if obj == nil {
@@ -188,49 +665,328 @@ func (p *Parser) packageStructs(pkg *types.Package, fileName string, declaredStr
 			continue
 		}
 
-		name = typ.Obj().Name()
-
 		str := &Struct{
-			Name:     name,
-			pkg:      pkg,
-			FileName: fileName,
-			named:    typ,
-			methods:  []*Method{},
-		}
-
-		n2 := typ.NumMethods()
-		prevPos := 0
-		for loop := 0; loop < n2; loop++ {
-			f := typ.Method(loop)
-			sig, ok := f.Type().Underlying().(*types.Signature)
-			if !ok {
+			Name:        typ.Obj().Name(),
+			FileName:    fileName,
+			Comment:     p.docs[typ.Obj().Pos()],
+			pkg:         pkg,
+			named:       typ,
+			methods:     []*Method{},
+			docs:        p.docs,
+			constraints: p.constraints,
+			sources:     p.sources,
+			unexported:  p.unexported,
+		}
+
+		switch u := typ.Underlying().(type) {
+		case *types.Interface:
+			str.Kind = KindInterface
+
+			mset := types.NewMethodSet(typ)
+			for loop := 0; loop < mset.Len(); loop++ {
+				f, ok := mset.At(loop).Obj().(*types.Func)
+				if !ok {
+					continue
+				}
+
+				sig, ok := f.Type().Underlying().(*types.Signature)
+				if !ok {
+					continue
+				}
+
+				str.methods = append(str.methods, &Method{
+					Comment:   p.docs[f.Pos()],
+					Path:      interfaceMethodPath(u, f),
+					_func:     f,
+					signature: sig,
+					onValue:   true,
+					sources:   p.sources,
+				})
+			}
+		case *types.Signature:
+			str.Kind = KindFunc
+			str.signature = &Method{
+				Comment:   str.Comment,
+				_func:     types.NewFunc(typ.Obj().Pos(), pkg, typ.Obj().Name(), u),
+				signature: u,
+				onValue:   true,
+				sources:   p.sources,
+			}
+
+			str.methods = newMethods(typ, p.docs, p.sources)
+		default:
+			continue
+		}
+
+		interfaces = append(interfaces, str)
+	}
+
+	return interfaces
+}
+
+// Enums returns named basic types which have typed constants declared
+func (p *Parser) Enums() []*Struct {
+	enums := make([]*Struct, 0)
+	for _, entry := range p.entries {
+		scope := entry.pkg.Types.Scope()
+		for _, name := range entry.enums {
+			obj := scope.Lookup(name)
+			if obj == nil {
 				continue
 			}
 
-			method := &Method{_func: f, signature: sig}
+			typ, ok := obj.Type().(*types.Named)
+			if !ok || typ.Obj().Pkg() == nil {
+				continue
+			}
 
-			if index := searchComment(comments, int(f.Pos()), prevPos); index != -1 {
-				method.Comment = comments[index].Text()
+			enum := newEnum(typ, p.docs)
+			if enum == nil {
+				continue
+			}
+
+			str := &Struct{
+				Name:        typ.Obj().Name(),
+				Kind:        KindEnum,
+				FileName:    entry.fileName,
+				Comment:     p.docs[typ.Obj().Pos()],
+				Enum:        enum,
+				pkg:         entry.pkg.Types,
+				named:       typ,
+				docs:        p.docs,
+				constraints: p.constraints,
+				sources:     p.sources,
+				unexported:  p.unexported,
+			}
+
+			str.methods = newMethods(typ, p.docs, p.sources)
+			enums = append(enums, str)
+		}
+	}
+
+	return enums
+}
+
+// Packages returns the info of every loaded package declaring exported
+// functions, constants or variables, described by its package comment
+func (p *Parser) Packages() []*Struct {
+	var (
+		pkgs = make([]*Struct, 0)
+		seen = map[string]*Struct{}
+	)
+
+	for _, entry := range p.entries {
+		// with tests, files of a package are split between it and its test
+		// variant, both make one info
+		pkg := entry.pkg.Types
+		str, ok := seen[pkg.Path()]
+		if !ok {
+			str = &Struct{
+				Name:        packageInfoName(entry.pkg),
+				Kind:        KindPackage,
+				FileName:    entry.fileName,
+				pkg:         pkg,
+				docs:        p.docs,
+				constraints: p.constraints,
+				sources:     p.sources,
 			}
+			seen[pkg.Path()] = str
+			pkgs = append(pkgs, str)
+		}
+
+		if strings.HasSuffix(entry.fileName, "_test.go") {
+			// the test variant also declares the other files of the package
+			str.pkg = pkg
+		} else if str.IsTest() {
+			str.FileName = entry.fileName
+		}
+
+		if str.Comment == "" {
+			str.Comment = p.docs[entry.syntax.Name.Pos()]
+		}
+		str.values = append(str.values, newValueBlocks(pkg, entry.syntax, p.valueFilter, p.docs, p.sources)...)
+	}
+
+	declaring := make([]*Struct, 0, len(pkgs))
+	for _, str := range pkgs {
+		if len(str.values) > 0 || len(str.Functions()) > 0 {
+			declaring = append(declaring, str)
+		}
+	}
+	return declaring
+}
+
+// interfaceMethodPath returns the embedded interfaces method is declared in
+func interfaceMethodPath(iface *types.Interface, f *types.Func) []string {
+	for loop := 0; loop < iface.NumExplicitMethods(); loop++ {
+		if iface.ExplicitMethod(loop) == f {
+			return nil
+		}
+	}
+
+	for loop := 0; loop < iface.NumEmbeddeds(); loop++ {
+		embedded := iface.EmbeddedType(loop)
+		inner, ok := embedded.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+
+		path := interfaceMethodPath(inner, f)
+		if path == nil && !hasMethod(inner, f) {
+			continue
+		}
+
+		name := embedded.String()
+		if named, ok := embedded.(*types.Named); ok {
+			name = named.Obj().Name()
+		}
+		return append([]string{name}, path...)
+	}
+
+	return nil
+}
+
+func hasMethod(iface *types.Interface, f *types.Func) bool {
+	for loop := 0; loop < iface.NumMethods(); loop++ {
+		if iface.Method(loop) == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) packageStructs(pkg *types.Package, fileName string, declaredStructs []string, structs []*Struct) []*Struct {
+	scope := pkg.Scope()
+
+	for _, name := range declaredStructs {
+		obj := scope.Lookup(name)
+
+		if obj == nil {
+			continue
+		}
 
-			str.methods = append(str.methods, method)
-			prevPos = int(f.Pos())
+		typ, ok := obj.Type().(*types.Named)
+		if !ok || typ.Obj().Pkg() == nil {
+			continue
 		}
 
-		structs = append(structs, str)
+		structs = append(structs, p.newStruct(pkg, fileName, typ))
 	}
 
 	return structs
 }
 
-// Naive search, will be improved later
-func searchComment(comments []*ast.CommentGroup, pos int, upper int) int {
-	length := len(comments)
-	for loop := length - 1; loop >= 0; loop-- {
-		commentPos := int(comments[loop].Pos())
-		if commentPos <= pos && commentPos > upper {
-			return loop
+// newStruct builds the info of named struct type with its declared and
+// promoted methods. Methods are documented by the doc comment of their
+// declaration, whichever file of the package it is in
+func (p *Parser) newStruct(pkg *types.Package, fileName string, typ *types.Named) *Struct {
+	str := &Struct{
+		Name:        typ.Obj().Name(),
+		Kind:        KindStruct,
+		FileName:    fileName,
+		Comment:     p.docs[typ.Obj().Pos()],
+		TypeParams:  typeParams(typ, p.qualifier.For(pkg)),
+		pkg:         pkg,
+		named:       typ,
+		docs:        p.docs,
+		constraints: p.constraints,
+		sources:     p.sources,
+		unexported:  p.unexported,
+		qualifier:   p.qualifier,
+	}
+
+	str.methods = newMethods(typ, p.docs, p.sources)
+	return str
+}
+
+// Instances returns concrete instantiations of generic structs that appear
+// as field types of declared structs
+func (p *Parser) Instances() []*Struct {
+	var (
+		instances = make([]*Struct, 0)
+		seen      = map[string]bool{}
+	)
+
+	for _, entry := range p.entries {
+		scope := entry.pkg.Types.Scope()
+		for _, name := range entry.structs {
+			obj := scope.Lookup(name)
+			if obj == nil {
+				continue
+			}
+
+			str, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+
+			for loop := 0; loop < str.NumFields(); loop++ {
+				named := instantiatedStruct(str.Field(loop).Type())
+				if named == nil {
+					continue
+				}
+
+				// instances and their files are named by package name whatever
+				// the qualifier, import paths would leak into file names
+				name := types.TypeString(named, packageNameQualifier(named.Obj().Pkg()))
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+
+				instance := p.newStruct(named.Obj().Pkg(), entry.fileName, named)
+				instance.Name = name
+				instance.TypeArgs = typeArgs(named, p.qualifier.For(named.Obj().Pkg()))
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	return instances
+}
+
+// instantiatedStruct returns the concrete instantiation of a generic struct
+// typ refers to, through pointers, slices, arrays and maps
+func instantiatedStruct(typ types.Type) *types.Named {
+	switch t := typ.(type) {
+	case *types.Pointer:
+		return instantiatedStruct(t.Elem())
+	case *types.Slice:
+		return instantiatedStruct(t.Elem())
+	case *types.Array:
+		return instantiatedStruct(t.Elem())
+	case *types.Map:
+		return instantiatedStruct(t.Elem())
+	case *types.Named:
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return nil
+		}
+		if !isInstance(t) {
+			return nil
+		}
+		return t
+	}
+	return nil
+}
+
+// packageNameQualifier qualifies types of other packages than own by package name
+// packageInfoName names the info of pkg after its path in its module,
+// packages of different directories often share their name
+func packageInfoName(pkg *packages.Package) string {
+	if pkg.Module == nil {
+		return pkg.PkgPath
+	}
+	if rel, ok := strings.CutPrefix(pkg.PkgPath, pkg.Module.Path+"/"); ok {
+		return rel
+	}
+	return pkg.Name
+}
+
+func packageNameQualifier(own *types.Package) types.Qualifier {
+	return func(pkg *types.Package) string {
+		if pkg == own {
+			return ""
 		}
+		return pkg.Name()
 	}
-	return -1
 }
@@ -0,0 +1,70 @@
+package gens
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackagesWithTests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/fact\n\ngo 1.21\n",
+		"fact.go":        "package fact\n\n// Max is the highest fact value\nconst Max = 10\n\nfunc Sum(values ...int) int { return 0 }\n",
+		"fact_test.go":   "package fact\n\n// Fixture is a fact value used by tests\nvar Fixture = 1\n",
+		"extern_test.go": "package fact_test\n\n// Other is declared by the external test package\nconst Other = 2\n",
+		"sub/fact.go":    "package fact\n\n// Min is the lowest fact value\nconst Min = 0\n",
+	}
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	walker := Walker{BaseDirs: []string{dir}, Recursive: true, IncludeTests: true}
+	structs, err := walker.Structs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos := map[string][]*Struct{}
+	for _, str := range structs {
+		if str.Kind == KindPackage {
+			infos[str.String()] = append(infos[str.String()], str)
+		}
+	}
+
+	fact := infos["example.com/fact"]
+	if len(fact) != 1 {
+		t.Fatalf("got %d infos of example.com/fact, want 1", len(fact))
+	}
+	if str := fact[0]; str.Name != "fact" {
+		t.Errorf("Name = %s, want fact", str.Name)
+	}
+	if str := fact[0]; str.IsTest() || filepath.Base(str.FileName) != "fact.go" {
+		t.Errorf("FileName = %s, IsTest() = %v, want fact.go not a test", str.FileName, str.IsTest())
+	}
+
+	var values []string
+	for _, block := range fact[0].Values() {
+		for _, v := range block.Values {
+			values = append(values, v.Name())
+		}
+	}
+	if got := strings.Join(values, ","); got != "Max,Fixture" {
+		t.Errorf("values = %s, want Max,Fixture", got)
+	}
+
+	if sub := infos["example.com/fact/sub"]; len(sub) != 1 || sub[0].Name != "sub" {
+		t.Errorf("got %d infos of example.com/fact/sub, want 1 named sub", len(sub))
+	}
+
+	if extern := infos["example.com/fact_test"]; len(extern) != 1 || !extern[0].IsTest() {
+		t.Errorf("got %d infos of example.com/fact_test, want 1 test info", len(extern))
+	}
+}
@@ -0,0 +1,236 @@
+package gens
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+const (
+	ConflictShadowed  = "shadowed"
+	ConflictAmbiguous = "ambiguous"
+)
+
+// Conflict reports a field or method which can not be selected through its
+// embedding path, either because a shallower one shadows it or because
+// several ones at the same depth make the selector ambiguous
+type Conflict struct {
+	Name string
+	Kind string
+	// Selector is the full path of the hidden field or method
+	Selector string
+	// By is the selector that wins over Selector, empty when ambiguous
+	By string `json:",omitempty"`
+}
+
+// candidate is a field or method reachable through embedded fields
+type candidate struct {
+	name   string
+	index  []int
+	path   []string
+	field  *types.Var
+	tag    Tag
+	rawTag string
+}
+
+func (c *candidate) selector() string {
+	return strings.Join(append(append([]string{}, c.path...), c.name), ".")
+}
+
+// promoted collects every field and method name reachable from named
+// through embedded fields, following the order of declaration
+type promoted struct {
+	named      *types.Named
+	order      []string
+	candidates map[string][]*candidate
+}
+
+func newPromoted(named *types.Named) *promoted {
+	p := &promoted{
+		named:      named,
+		candidates: map[string][]*candidate{},
+	}
+
+	p.walk(named, nil, nil, map[*types.Named]bool{named: true})
+	return p
+}
+
+func (p *promoted) add(c *candidate) {
+	if _, ok := p.candidates[c.name]; !ok {
+		p.order = append(p.order, c.name)
+	}
+	p.candidates[c.name] = append(p.candidates[c.name], c)
+}
+
+func (p *promoted) walk(typ types.Type, index []int, path []string, visiting map[*types.Named]bool) {
+	named, _ := typ.(*types.Named)
+
+	if len(path) > 0 && named != nil {
+		for loop := 0; loop < named.NumMethods(); loop++ {
+			f := named.Method(loop)
+			p.add(&candidate{name: f.Name(), index: append(append([]int{}, index...), loop), path: path})
+		}
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Interface:
+		if len(path) == 0 {
+			return
+		}
+		for loop := 0; loop < t.NumMethods(); loop++ {
+			f := t.Method(loop)
+			p.add(&candidate{name: f.Name(), index: append(append([]int{}, index...), loop), path: path})
+		}
+	case *types.Struct:
+		for loop := 0; loop < t.NumFields(); loop++ {
+			v := t.Field(loop)
+			c := &candidate{
+				name:   v.Name(),
+				index:  append(append([]int{}, index...), loop),
+				path:   path,
+				field:  v,
+				tag:    ParseTag(t.Tag(loop)),
+				rawTag: t.Tag(loop),
+			}
+			p.add(c)
+
+			if !v.Anonymous() || c.tag.Ignore {
+				continue
+			}
+
+			embedded := v.Type()
+			if ptr, ok := embedded.(*types.Pointer); ok {
+				embedded = ptr.Elem()
+			}
+
+			n, ok := embedded.(*types.Named)
+			if !ok || visiting[n] {
+				continue
+			}
+
+			visiting[n] = true
+			p.walk(n, c.index, append(append([]string{}, path...), v.Name()), visiting)
+			delete(visiting, n)
+		}
+	}
+}
+
+// lookup resolves name the way a selector would, returns the winning
+// candidate, nil when the name is ambiguous
+func (p *promoted) lookup(name string) (*candidate, bool) {
+	obj, index, _ := types.LookupFieldOrMethod(p.named, true, p.named.Obj().Pkg(), name)
+	if obj == nil {
+		return nil, index != nil
+	}
+
+	for _, c := range p.candidates[name] {
+		if equalIndex(c.index, index) {
+			return c, false
+		}
+	}
+
+	// declared method of the struct itself
+	return nil, false
+}
+
+// Conflicts returns shadowed and ambiguous names
+func (p *promoted) Conflicts() []*Conflict {
+	conflicts := make([]*Conflict, 0)
+	for _, name := range p.order {
+		if !ast.IsExported(name) {
+			continue
+		}
+
+		candidates := p.candidates[name]
+		winner, ambiguous := p.lookup(name)
+
+		for _, c := range candidates {
+			switch {
+			case ambiguous:
+				conflicts = append(conflicts, &Conflict{
+					Name:     name,
+					Kind:     ConflictAmbiguous,
+					Selector: c.selector(),
+				})
+			case c != winner:
+				by := name
+				if winner != nil {
+					by = winner.selector()
+				}
+				conflicts = append(conflicts, &Conflict{
+					Name:     name,
+					Kind:     ConflictShadowed,
+					Selector: c.selector(),
+					By:       by,
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// Fields returns fields selectable from the struct, embedded structs are
+// flattened into their promoted fields
+func (p *promoted) Fields() []*candidate {
+	fields := make([]*candidate, 0)
+	for _, name := range p.order {
+		c, _ := p.lookup(name)
+		if c == nil || c.field == nil || c.tag.Ignore || isEmbeddedStruct(c.field) {
+			continue
+		}
+
+		fields = append(fields, c)
+	}
+
+	return fields
+}
+
+// isEmbeddedStruct reports whether v is an embedded field flattened into its parent
+func isEmbeddedStruct(v *types.Var) bool {
+	if !v.Anonymous() {
+		return false
+	}
+
+	typ := v.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	_, ok := typ.Underlying().(*types.Struct)
+	return ok
+}
+
+// embeddingPath returns names of the embedded fields index goes through
+func embeddingPath(typ types.Type, index []int) []string {
+	path := make([]string, 0, len(index))
+	for _, i := range index {
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+
+		str, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+
+		v := str.Field(i)
+		path = append(path, v.Name())
+		typ = v.Type()
+	}
+
+	return path
+}
+
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for loop := range a {
+		if a[loop] != b[loop] {
+			return false
+		}
+	}
+	return true
+}
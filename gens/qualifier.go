@@ -0,0 +1,59 @@
+package gens
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
+
+const (
+	QualifierFull     = "full"
+	QualifierPackage  = "package"
+	QualifierRelative = "relative"
+	QualifierAlias    = "alias"
+)
+
+// Qualifier controls how packages are named in type strings, by full import
+// path, by package name, relative to the package of the generated struct or
+// by user supplied aliases of import path prefixes
+type Qualifier struct {
+	Mode    string
+	Aliases map[string]string
+}
+
+// For returns the types.Qualifier of struct declared in own package
+func (q Qualifier) For(own *types.Package) types.Qualifier {
+	switch q.Mode {
+	case QualifierPackage:
+		return func(pkg *types.Package) string {
+			return pkg.Name()
+		}
+	case QualifierRelative:
+		return types.RelativeTo(own)
+	case QualifierAlias:
+		return q.alias
+	}
+	return nil
+}
+
+// alias replaces the longest aliased prefix of package path
+func (q Qualifier) alias(pkg *types.Package) string {
+	prefixes := make([]string, 0, len(q.Aliases))
+	for prefix := range q.Aliases {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	path := pkg.Path()
+	for _, prefix := range prefixes {
+		if path == prefix {
+			return q.Aliases[prefix]
+		}
+		if strings.HasPrefix(path, prefix+"/") {
+			return q.Aliases[prefix] + strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
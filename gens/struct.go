@@ -3,24 +3,56 @@ package gens
 import (
 	"go/ast"
 	"go/types"
+	"strings"
 )
 
+const (
+	KindStruct    = "struct"
+	KindInterface = "interface"
+	KindFunc      = "func"
+	KindEnum      = "enum"
+	KindPackage   = "package"
+)
+
+// Struct holds info of a declared named type, mostly a struct, Kind tells
+// whether it is an interface, a named function type or an enum instead
 type Struct struct {
 	Name     string
+	Kind     string
 	FileName string
 	Comment  string
-	methods  []*Method
-	File     *ast.File
-	pkg      *types.Package
-	named    *types.Named
-
-	comments []*ast.CommentGroup
+	// TypeParams are type parameters of a generic struct
+	TypeParams []*TypeParam
+	// Enum holds typed constants of a named basic type
+	Enum *Enum
+	// TypeArgs are type arguments of an instantiated generic struct
+	TypeArgs []string
+	// Diagnostics are errors of the struct's package located in its file
+	Diagnostics []*Diagnostic
+	// Module is the module the struct is declared in, nil when unknown
+	Module      *Module
+	methods     []*Method
+	File        *ast.File
+	pkg         *types.Package
+	named       *types.Named
+	docs        docs
+	constraints constraints
+	sources     sources
+	signature   *Method
+	// values are constants and variables of a package info
+	values []*ValueBlock
+	// unexported includes unexported fields and methods
+	unexported bool
+	// qualifier names packages in type parameters of nested structs
+	qualifier Qualifier
 }
 
+// Methods returns exported methods of struct, unexported ones too when the
+// parser includes them
 func (this *Struct) Methods() []*Method {
 	methods := make([]*Method, 0, len(this.methods))
 	for _, m := range this.methods {
-		if m._func.Exported() {
+		if m.Exported() || this.unexported {
 			methods = append(methods, m)
 		}
 	}
@@ -28,28 +60,109 @@ func (this *Struct) Methods() []*Method {
 	return methods
 }
 
+// Fields returns exported fields of struct, unexported ones too when the
+// parser includes them. Fields of embedded structs are promoted the way
+// selectors resolve them
 func (this *Struct) Fields() []*Field {
 	fields := make([]*Field, 0)
-	str, ok := this.named.Underlying().(*types.Struct)
-	if !ok {
+	if this.named == nil {
+		return fields
+	}
+	if _, ok := this.named.Underlying().(*types.Struct); !ok {
 		return fields
 	}
 
-	count := str.NumFields()
-
-	for loop := 0; loop < count; loop++ {
-		v := str.Field(loop)
-		if v.Exported() {
-			fields = append(fields, &Field{_var: v})
+	for _, c := range newPromoted(this.named).Fields() {
+		v := c.field
+		if !v.Exported() && !this.unexported {
+			continue
 		}
+
+		fields = append(fields, &Field{
+			Comment:     this.docs[v.Pos()],
+			Tag:         c.tag,
+			RawTag:      c.rawTag,
+			Path:        c.path,
+			Constraint:  this.constraints[v.Pos()],
+			Source:      this.sources[v.Pos()],
+			_var:        v,
+			docs:        this.docs,
+			constraints: this.constraints,
+			sources:     this.sources,
+			unexported:  this.unexported,
+			qualifier:   this.qualifier,
+		})
 	}
 	return fields
 }
 
+// Conflicts returns fields and methods hidden by embedding
+func (this *Struct) Conflicts() []*Conflict {
+	if this.named == nil {
+		return []*Conflict{}
+	}
+	if _, ok := this.named.Underlying().(*types.Struct); !ok {
+		return []*Conflict{}
+	}
+
+	return newPromoted(this.named).Conflicts()
+}
+
+// Constraint returns the build constraint of the file struct is declared
+// in, empty when it is built under every configuration
+func (this *Struct) Constraint() string {
+	if this.named == nil {
+		return ""
+	}
+	return this.constraints[this.named.Obj().Pos()]
+}
+
+// IsTest reports whether struct is declared in a _test.go file
+func (this *Struct) IsTest() bool {
+	return strings.HasSuffix(this.FileName, "_test.go")
+}
+
+// Signature returns the signature of a named function type, nil otherwise
+func (this *Struct) Signature() *Method {
+	return this.signature
+}
+
 func (this *Struct) String() string {
+	if this.named == nil {
+		return this.pkg.Path()
+	}
 	return this.named.String()
 }
 
+// Functions returns exported package-level functions of a package info
+func (this *Struct) Functions() []*Method {
+	if this.Kind != KindPackage {
+		return []*Method{}
+	}
+	return newFunctions(this.pkg, this.docs, this.sources)
+}
+
+// Values returns exported package-level constants and variables of a
+// package info grouped by declaration
+func (this *Struct) Values() []*ValueBlock {
+	return this.values
+}
+
+// Constructors returns package-level functions building the struct
+func (this *Struct) Constructors() []*Method {
+	constructors := make([]*Method, 0)
+	if this.named == nil || this.Kind != KindStruct {
+		return constructors
+	}
+
+	for _, f := range newFunctions(this.named.Obj().Pkg(), this.docs, this.sources) {
+		if named := f.constructs(); named != nil && named.Obj() == this.named.Obj() {
+			constructors = append(constructors, f)
+		}
+	}
+	return constructors
+}
+
 // func NewStruct(obj types.Object) *Struct {
 // 	if obj == nil {
 // 		return nil
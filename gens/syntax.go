@@ -0,0 +1,539 @@
+package gens
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	// ModePackages loads packages with go/packages, dependencies must be available
+	ModePackages = "packages"
+	// ModeSyntax parses packages with go/parser alone, types of other
+	// packages are taken as written in source and left unresolved
+	ModeSyntax = "syntax"
+)
+
+// syntaxLoader loads packages from source without their dependencies.
+// In-package types are resolved by go/types, every other import is replaced
+// by a package declaring the names the sources select from it
+type syntaxLoader struct {
+	fset    *token.FileSet
+	build   build.Context
+	tests   bool
+	overlay map[string][]byte
+	// checked are loaded packages by path, imported by their external tests
+	checked map[string]*types.Package
+}
+
+func newSyntaxLoader(conf packages.Config, b BuildConfig) *syntaxLoader {
+	l := &syntaxLoader{
+		fset:    conf.Fset,
+		build:   build.Default,
+		tests:   conf.Tests,
+		overlay: conf.Overlay,
+		checked: map[string]*types.Package{},
+	}
+
+	l.build.BuildTags = b.Tags
+	if b.GOOS != "" {
+		l.build.GOOS = b.GOOS
+	}
+	if b.GOARCH != "" {
+		l.build.GOARCH = b.GOARCH
+	}
+	l.build.OpenFile = func(path string) (io.ReadCloser, error) {
+		if contents, ok := l.overlay[path]; ok {
+			return ioutil.NopCloser(bytes.NewReader(contents)), nil
+		}
+		return os.Open(path)
+	}
+
+	return l
+}
+
+// Load returns packages of directory patterns. Import paths can not be
+// resolved without go list, the first one is returned as error
+func (l *syntaxLoader) Load(patterns ...string) ([]*packages.Package, error) {
+	var (
+		dirs  []string
+		seen  = map[string]bool{}
+		first error
+	)
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			if first == nil {
+				first = fmt.Errorf("%s mode only supports directory patterns, got %s", ModeSyntax, pattern)
+			}
+			continue
+		}
+
+		root := patternRoot(pattern)
+		found := []string{root}
+		if strings.HasSuffix(pattern, recursivePattern) {
+			found = append(found, subDirs(root)...)
+		}
+
+		for _, dir := range found {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	pkgs := make([]*packages.Package, 0, len(dirs))
+	for _, dir := range dirs {
+		pkgs = append(pkgs, l.loadDir(dir)...)
+	}
+	return pkgs, first
+}
+
+// loadDir returns the package of dir, with its test variants when tests are loaded
+func (l *syntaxLoader) loadDir(dir string) []*packages.Package {
+	bp, err := l.build.ImportDir(dir, 0)
+	if _, ok := err.(*build.NoGoError); ok {
+		return nil
+	}
+
+	module := syntaxModule(dir)
+	pkgPath := bp.ImportPath
+	if module != nil {
+		rel, _ := filepath.Rel(module.Dir, dir)
+		pkgPath = path.Join(module.Path, filepath.ToSlash(rel))
+	}
+
+	goFiles := append(l.overlaid(dir, bp.GoFiles), bp.CgoFiles...)
+	pkg := l.check(pkgPath, pkgPath, bp.Name, dir, goFiles, module)
+	if err != nil {
+		pkg.Errors = append([]packages.Error{{Msg: err.Error(), Kind: packages.ListError}}, pkg.Errors...)
+	}
+	pkgs := []*packages.Package{pkg}
+
+	if !l.tests {
+		return pkgs
+	}
+
+	l.checked[pkgPath] = pkg.Types
+	if len(bp.TestGoFiles) > 0 {
+		files := append(append([]string{}, goFiles...), bp.TestGoFiles...)
+		pkgs = append(pkgs, l.check(pkgPath+" ["+pkgPath+".test]", pkgPath, bp.Name, dir, files, module))
+	}
+	if len(bp.XTestGoFiles) > 0 {
+		id := pkgPath + "_test [" + pkgPath + ".test]"
+		pkgs = append(pkgs, l.check(id, pkgPath+"_test", bp.Name+"_test", dir, bp.XTestGoFiles, module))
+	}
+	delete(l.checked, pkgPath)
+
+	return pkgs
+}
+
+// overlaid adds overlay files of dir missing on disk to files
+func (l *syntaxLoader) overlaid(dir string, files []string) []string {
+	files = append([]string{}, files...)
+
+	listed := map[string]bool{}
+	for _, f := range files {
+		listed[f] = true
+	}
+
+	for path := range l.overlay {
+		name := filepath.Base(path)
+		if filepath.Dir(path) != dir || listed[name] || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := l.build.MatchFile(dir, name); err == nil && ok {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+func (l *syntaxLoader) check(id, pkgPath, name, dir string, files []string, module *packages.Module) *packages.Package {
+	pkg := &packages.Package{
+		ID:      id,
+		Name:    name,
+		PkgPath: pkgPath,
+		Fset:    l.fset,
+		Module:  module,
+		TypesInfo: &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Implicits:  map[ast.Node]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+			Scopes:     map[ast.Node]*types.Scope{},
+		},
+	}
+
+	for _, f := range files {
+		fileName := filepath.Join(dir, f)
+		pkg.GoFiles = append(pkg.GoFiles, fileName)
+
+		var src interface{}
+		if contents, ok := l.overlay[fileName]; ok {
+			src = contents
+		}
+
+		file, err := parser.ParseFile(l.fset, fileName, src, parser.ParseComments)
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				pkg.Errors = append(pkg.Errors, packages.Error{Pos: e.Pos.String(), Msg: e.Msg, Kind: packages.ParseError})
+			}
+		} else if err != nil {
+			pkg.Errors = append(pkg.Errors, packages.Error{Msg: err.Error(), Kind: packages.ParseError})
+		}
+		if file != nil {
+			pkg.Syntax = append(pkg.Syntax, file)
+		}
+	}
+
+	conf := types.Config{
+		Importer:         newSyntaxImporter(pkg.Syntax, l.checked),
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error: func(err error) {
+			pos := ""
+			if terr, ok := err.(types.Error); ok {
+				pos = terr.Fset.Position(terr.Pos).String()
+				err = fmt.Errorf("%s", terr.Msg)
+			}
+			pkg.Errors = append(pkg.Errors, packages.Error{Pos: pos, Msg: err.Error(), Kind: packages.TypeError})
+		},
+	}
+
+	pkg.Types, _ = conf.Check(pkgPath, l.fset, pkg.Syntax, pkg.TypesInfo)
+	pkg.IllTyped = len(pkg.Errors) > 0
+	return pkg
+}
+
+// syntaxImporter declares, for every imported path, the names selected from
+// it in the sources. Names used as types are named types with an invalid
+// underlying type, so they are kept as written without being taken for
+// interfaces or structs, names used as values are variables or functions
+// of invalid type. What the sources do not tell stays unknown:
+//   - fields and methods of embedded imported types are not promoted
+//   - types declared from an imported type, like type Local pkg.Remote, have
+//     an invalid underlying type and are left out
+//   - array lengths of imported constants and conversions to imported types
+//     are reported as type errors
+type syntaxImporter struct {
+	selected map[string]map[string]*selection
+	names    map[string]string
+	checked  map[string]*types.Package
+	imported map[string]*types.Package
+}
+
+// selection tells how a name selected from an import is used
+type selection struct {
+	// typ is set for names used as types, the others are taken as values
+	typ bool
+	// called is set for values called as functions
+	called bool
+	// typeParams is the number of type arguments the type is instantiated with
+	typeParams int
+}
+
+func newSyntaxImporter(files []*ast.File, checked map[string]*types.Package) *syntaxImporter {
+	im := &syntaxImporter{
+		selected: map[string]map[string]*selection{},
+		names:    map[string]string{},
+		checked:  checked,
+		imported: map[string]*types.Package{},
+	}
+
+	for _, file := range files {
+		locals := map[string]string{}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+
+			name := importName(importPath)
+			if spec.Name != nil && spec.Name.Name != "_" && spec.Name.Name != "." {
+				locals[spec.Name.Name] = importPath
+			} else {
+				locals[name] = importPath
+			}
+			if _, ok := im.names[importPath]; !ok {
+				im.names[importPath] = name
+			}
+			if im.selected[importPath] == nil {
+				im.selected[importPath] = map[string]*selection{}
+			}
+		}
+
+		for _, decl := range file.Decls {
+			ast.Walk(selectVisitor{im: im, locals: locals}, decl)
+		}
+	}
+
+	return im
+}
+
+func (im *syntaxImporter) Import(importPath string) (*types.Package, error) {
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+	if pkg, ok := im.checked[importPath]; ok {
+		return pkg, nil
+	}
+	if pkg, ok := im.imported[importPath]; ok {
+		return pkg, nil
+	}
+
+	pkg := types.NewPackage(importPath, im.names[importPath])
+	for name, sel := range im.selected[importPath] {
+		switch {
+		case !sel.typ && sel.called:
+			pkg.Scope().Insert(types.NewFunc(token.NoPos, pkg, name, unknownFunc))
+			continue
+		case !sel.typ:
+			pkg.Scope().Insert(types.NewVar(token.NoPos, pkg, name, types.Typ[types.Invalid]))
+			continue
+		}
+
+		obj := types.NewTypeName(token.NoPos, pkg, name, nil)
+		named := types.NewNamed(obj, types.Typ[types.Invalid], nil)
+		if sel.typeParams > 0 {
+			typeParams := make([]*types.TypeParam, 0, sel.typeParams)
+			for loop := 0; loop < sel.typeParams; loop++ {
+				param := types.NewTypeName(token.NoPos, pkg, "T"+strconv.Itoa(loop), nil)
+				typeParams = append(typeParams, types.NewTypeParam(param, types.Universe.Lookup("any").Type()))
+			}
+			named.SetTypeParams(typeParams)
+		}
+		pkg.Scope().Insert(obj)
+	}
+	pkg.MarkComplete()
+
+	im.imported[importPath] = pkg
+	return pkg, nil
+}
+
+// unknownFunc is the signature of imported functions, taking any arguments
+// and returning a value of invalid type
+var unknownFunc = types.NewSignatureType(nil, nil, nil,
+	types.NewTuple(types.NewParam(token.NoPos, nil, "args", types.NewSlice(types.Universe.Lookup("any").Type()))),
+	types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Typ[types.Invalid])),
+	true)
+
+// selectVisitor records the names selected from imports, value tells
+// whether visited expressions are values rather than types. Function
+// bodies are skipped as they are not type-checked
+type selectVisitor struct {
+	im     *syntaxImporter
+	locals map[string]string
+	value  bool
+}
+
+func (v selectVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		if n.Recv != nil {
+			ast.Walk(v, n.Recv)
+		}
+		ast.Walk(v, n.Type)
+		return nil
+	case *ast.FuncLit:
+		ast.Walk(v.types(), n.Type)
+		return nil
+	case *ast.ValueSpec:
+		if n.Type != nil {
+			ast.Walk(v.types(), n.Type)
+		}
+		for _, value := range n.Values {
+			ast.Walk(v.values(), value)
+		}
+		return nil
+	case *ast.ArrayType:
+		if n.Len != nil {
+			ast.Walk(v.values(), n.Len)
+		}
+		ast.Walk(v.types(), n.Elt)
+		return nil
+	case *ast.CompositeLit:
+		if n.Type != nil {
+			ast.Walk(v.types(), n.Type)
+		}
+		for _, elt := range n.Elts {
+			ast.Walk(v.values(), elt)
+		}
+		return nil
+	case *ast.TypeAssertExpr:
+		ast.Walk(v.values(), n.X)
+		if n.Type != nil {
+			ast.Walk(v.types(), n.Type)
+		}
+		return nil
+	case *ast.CallExpr:
+		// new and make take a type first
+		if ident, ok := n.Fun.(*ast.Ident); ok && (ident.Name == "new" || ident.Name == "make") && len(n.Args) > 0 {
+			ast.Walk(v.types(), n.Args[0])
+			for _, arg := range n.Args[1:] {
+				ast.Walk(v.values(), arg)
+			}
+			return nil
+		}
+		if sel, ok := n.Fun.(*ast.SelectorExpr); ok && v.value {
+			if s := v.selectName(sel, 0); s != nil {
+				s.called = true
+			}
+		}
+	case *ast.IndexExpr:
+		v.selectIndexed(n.X, 1)
+	case *ast.IndexListExpr:
+		v.selectIndexed(n.X, len(n.Indices))
+	case *ast.SelectorExpr:
+		v.selectName(n, 0)
+	case *ast.Field, *ast.Ellipsis, *ast.StructType, *ast.InterfaceType, *ast.FuncType, *ast.MapType, *ast.ChanType:
+		return v.types()
+	}
+	return v
+}
+
+func (v selectVisitor) types() selectVisitor {
+	v.value = false
+	return v
+}
+
+func (v selectVisitor) values() selectVisitor {
+	v.value = true
+	return v
+}
+
+// selectIndexed records x as a generic type when it is instantiated with
+// typeArgs type arguments
+func (v selectVisitor) selectIndexed(x ast.Expr, typeArgs int) {
+	if sel, ok := x.(*ast.SelectorExpr); ok && !v.value {
+		v.selectName(sel, typeArgs)
+	}
+}
+
+// selectName records the name sel selects from an import, it returns nil
+// when sel does not select from an import
+func (v selectVisitor) selectName(sel *ast.SelectorExpr, typeParams int) *selection {
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	importPath, ok := v.locals[x.Name]
+	if !ok {
+		return nil
+	}
+
+	s, ok := v.im.selected[importPath][sel.Sel.Name]
+	if !ok {
+		s = &selection{}
+		v.im.selected[importPath][sel.Sel.Name] = s
+	}
+	if !v.value {
+		s.typ = true
+		s.typeParams = max(s.typeParams, typeParams)
+	}
+	return s
+}
+
+// importName guesses the package name of an import path from its last
+// element, skipping major versions and gopkg.in style suffixes
+func importName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+
+	if index := strings.Index(name, ".v"); index > 0 {
+		name = name[:index]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// syntaxModule returns the module dir is in, read from the closest go.mod
+func syntaxModule(dir string) *packages.Module {
+	root, ok := findUp(dir, "go.mod")
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+
+		modulePath := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if unquoted, err := strconv.Unquote(modulePath); err == nil {
+			modulePath = unquoted
+		}
+		return &packages.Module{
+			Path:  modulePath,
+			Dir:   root,
+			GoMod: filepath.Join(root, "go.mod"),
+			Main:  true,
+		}
+	}
+	return nil
+}
+
+// subDirs returns directories below dir the way ./... matches them
+func subDirs(dir string) []string {
+	dirs := make([]string, 0)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return dirs
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor" {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		dirs = append(dirs, path)
+		dirs = append(dirs, subDirs(path)...)
+	}
+
+	return dirs
+}
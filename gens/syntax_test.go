@@ -0,0 +1,100 @@
+package gens
+
+import (
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestSyntaxImportedNames(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/fact\n\ngo 1.21\n",
+		"fact.go": `package fact
+
+import "example.com/dep"
+
+type Fact struct {
+	dep.Base
+	Value dep.Value
+	List  dep.List[int]
+	Pairs dep.Pair[string, *dep.Value]
+	Keys  map[dep.Key]int
+}
+
+type Reader interface {
+	dep.Reader
+	Read() Fact
+}
+
+type Local dep.Remote
+
+const Max = dep.Max
+
+var Default = dep.New()
+
+var Empty = dep.Empty{}
+
+func (f Fact) Convert(v dep.Value) dep.Result {
+	return dep.Convert(v)
+}
+`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := newSyntaxLoader(packages.Config{Fset: token.NewFileSet()}, BuildConfig{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("got %d packages, want 1", len(pkgs))
+	}
+	for _, e := range pkgs[0].Errors {
+		t.Errorf("unexpected error %s", e)
+	}
+
+	scope := pkgs[0].Types.Scope()
+	str := scope.Lookup("Fact").Type().Underlying().(*types.Struct)
+	want := map[string]string{
+		"Base":  "dep.Base",
+		"Value": "dep.Value",
+		"List":  "dep.List[int]",
+		"Pairs": "dep.Pair[string, *dep.Value]",
+		"Keys":  "map[dep.Key]int",
+	}
+	for loop := 0; loop < str.NumFields(); loop++ {
+		field := str.Field(loop)
+		typ := newType(field.Type())
+		if got := typ.TypeString(packageNameQualifier(nil)); got != want[field.Name()] {
+			t.Errorf("%s type = %s, want %s", field.Name(), got, want[field.Name()])
+		}
+		if typ.Invalid() {
+			t.Errorf("%s type is invalid", field.Name())
+		}
+	}
+
+	value := newType(str.Field(1).Type())
+	if value.Kind() != TypeKindNamed || value.PkgPath() != "example.com/dep" {
+		t.Errorf("Value kind = %s of %s, want named of example.com/dep", value.Kind(), value.PkgPath())
+	}
+	if _, ok := value.(*goType).typ.Underlying().(*types.Interface); ok {
+		t.Errorf("Value is taken for an interface")
+	}
+
+	reader := scope.Lookup("Reader").Type().Underlying().(*types.Interface)
+	if reader.NumMethods() != 1 || reader.Method(0).Name() != "Read" {
+		t.Errorf("Reader methods = %v, want Read", reader)
+	}
+
+	if local := scope.Lookup("Local").Type().Underlying(); local != types.Typ[types.Invalid] {
+		t.Errorf("Local underlying type = %s, want invalid type", local)
+	}
+}
@@ -0,0 +1,131 @@
+package gens
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	TagName = "tinfo"
+)
+
+// Tag holds the options of a `tinfo` struct tag. Options are separated by
+// commas, values containing commas can be wrapped in single quotes.
+//
+//	Value int64 `tinfo:"name=value,desc='Value of the fact, in cents',readonly"`
+type Tag struct {
+	// Ignore excludes the field from the generated info
+	Ignore bool
+	// Name overrides the emitted key of the field
+	Name string
+	// Desc is an inline description of the field
+	Desc string
+	// ReadOnly marks the field as not assignable by rules
+	ReadOnly bool
+	// Deprecated marks the field as deprecated
+	Deprecated bool
+}
+
+// ParseTag parses the `tinfo` key of a raw struct tag, unknown options are ignored
+func ParseTag(tag string) Tag {
+	var t Tag
+
+	value, ok := reflect.StructTag(tag).Lookup(TagName)
+	if !ok {
+		return t
+	}
+
+	for _, option := range splitTagOptions(value) {
+		key, val := option, ""
+		if index := strings.Index(option, "="); index != -1 {
+			key, val = option[:index], unquoteTagValue(option[index+1:])
+		}
+
+		switch strings.TrimSpace(key) {
+		case "ignore", "-":
+			t.Ignore = true
+		case "name":
+			t.Name = val
+		case "desc":
+			t.Desc = val
+		case "readonly":
+			t.ReadOnly = true
+		case "deprecated":
+			t.Deprecated = true
+		}
+	}
+
+	return t
+}
+
+func splitTagOptions(value string) []string {
+	var (
+		options []string
+		quoted  bool
+		start   int
+	)
+
+	for loop := 0; loop < len(value); loop++ {
+		switch value[loop] {
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				options = append(options, value[start:loop])
+				start = loop + 1
+			}
+		}
+	}
+
+	return append(options, value[start:])
+}
+
+func unquoteTagValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// ParseStructTag returns key value pairs of a raw struct tag following the
+// conventional `key:"value" key2:"value2"` format, malformed pairs stop parsing
+func ParseStructTag(tag string) map[string]string {
+	pairs := make(map[string]string)
+
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		index := strings.Index(tag, ":")
+		if index <= 0 || index+1 >= len(tag) || tag[index+1] != '"' {
+			break
+		}
+		key := tag[:index]
+		tag = tag[index+1:]
+
+		// scan to the closing quote, skipping escaped quotes
+		end := 1
+		for end < len(tag) && tag[end] != '"' {
+			if tag[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(tag) {
+			break
+		}
+
+		value, err := strconv.Unquote(tag[:end+1])
+		if err != nil {
+			break
+		}
+		pairs[key] = value
+		tag = tag[end+1:]
+	}
+
+	return pairs
+}
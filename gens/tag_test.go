@@ -0,0 +1,160 @@
+package gens
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want Tag
+	}{
+		{
+			name: "no tinfo key",
+			tag:  `json:"value"`,
+			want: Tag{},
+		},
+		{
+			name: "ignore",
+			tag:  `tinfo:"ignore"`,
+			want: Tag{Ignore: true},
+		},
+		{
+			name: "dash ignores",
+			tag:  `tinfo:"-"`,
+			want: Tag{Ignore: true},
+		},
+		{
+			name: "flags",
+			tag:  `tinfo:"readonly,deprecated"`,
+			want: Tag{ReadOnly: true, Deprecated: true},
+		},
+		{
+			name: "name and desc",
+			tag:  `json:"v" tinfo:"name=value,desc=Value of the fact"`,
+			want: Tag{Name: "value", Desc: "Value of the fact"},
+		},
+		{
+			name: "quoted desc keeps commas",
+			tag:  `tinfo:"name=value,desc='Value of the fact, in cents',readonly"`,
+			want: Tag{Name: "value", Desc: "Value of the fact, in cents", ReadOnly: true},
+		},
+		{
+			name: "spaces around options",
+			tag:  `tinfo:"name=value, readonly , desc= 'in cents' "`,
+			want: Tag{Name: "value", Desc: "in cents", ReadOnly: true},
+		},
+		{
+			name: "escaped quotes in tag value",
+			tag:  `tinfo:"desc=say \"hi\""`,
+			want: Tag{Desc: `say "hi"`},
+		},
+		{
+			name: "unknown options are ignored",
+			tag:  `tinfo:"required,name=value"`,
+			want: Tag{Name: "value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTag(tt.tag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTag(%q) = %+v, want %+v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want map[string]string
+	}{
+		{
+			name: "empty",
+			tag:  ``,
+			want: map[string]string{},
+		},
+		{
+			name: "pairs",
+			tag:  `json:"value,omitempty" yaml:"value"`,
+			want: map[string]string{"json": "value,omitempty", "yaml": "value"},
+		},
+		{
+			name: "extra spaces",
+			tag:  `  json:"value"   yaml:"v"`,
+			want: map[string]string{"json": "value", "yaml": "v"},
+		},
+		{
+			name: "escaped quote",
+			tag:  `desc:"say \"hi\"" json:"v"`,
+			want: map[string]string{"desc": `say "hi"`, "json": "v"},
+		},
+		{
+			name: "dash comma",
+			tag:  `json:"-,"`,
+			want: map[string]string{"json": "-,"},
+		},
+		{
+			name: "malformed pair stops parsing",
+			tag:  `json:"value" yaml:value xml:"v"`,
+			want: map[string]string{"json": "value"},
+		},
+		{
+			name: "unterminated value",
+			tag:  `json:"value`,
+			want: map[string]string{},
+		},
+		{
+			name: "missing key",
+			tag:  `:"value"`,
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseStructTag(tt.tag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseStructTag(%q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldKeyFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		source string
+		want   string
+		wantOk bool
+	}{
+		{name: "go name", tag: `json:"value"`, source: KeySourceGoName, want: "Value", wantOk: true},
+		{name: "json name", tag: `json:"value,omitempty"`, source: "json", want: "value", wantOk: true},
+		{name: "json without name", tag: `json:",omitempty"`, source: "json", want: "Value", wantOk: true},
+		{name: "json dash excludes", tag: `json:"-"`, source: "json", wantOk: false},
+		{name: "json dash comma names dash", tag: `json:"-,"`, source: "json", want: "-", wantOk: true},
+		{name: "missing key falls back", tag: `yaml:"v"`, source: "json", want: "Value", wantOk: true},
+		{name: "tinfo name wins", tag: `json:"value" tinfo:"name=amount"`, source: "json", want: "amount", wantOk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Field{
+				Tag:    ParseTag(tt.tag),
+				RawTag: tt.tag,
+				_var:   types.NewField(token.NoPos, nil, "Value", types.Typ[types.Int], false),
+			}
+
+			got, ok := f.KeyFrom(tt.source)
+			if ok != tt.wantOk || (ok && got != tt.want) {
+				t.Errorf("KeyFrom(%q) with %q = %q, %v, want %q, %v", tt.source, tt.tag, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
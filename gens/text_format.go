@@ -2,10 +2,18 @@ package gens
 
 import (
 	"fmt"
+	"go/types"
 	"strings"
 )
 
 type TextFormatter struct {
+	// Qualifier names packages in type strings, nil for full import paths
+	Qualifier types.Qualifier
+	// ExcludeUnexportedFields leaves out unexported fields, they are marked
+	// otherwise
+	ExcludeUnexportedFields bool
+	// ExcludeUnexportedMethods leaves out unexported methods the same way
+	ExcludeUnexportedMethods bool
 }
 
 func (TextFormatter) Start() string {
@@ -19,30 +27,53 @@ func (TextFormatter) Separate() string {
 func (TextFormatter) Struct(str *Struct) string {
 	builder := strings.Builder{}
 	builder.WriteString(fmt.Sprintf("Struct: %v", str.Name))
+	if str.Comment != "" {
+		builder.WriteString(NewLine)
+		builder.WriteString(strings.TrimSpace(str.Comment))
+	}
 	return builder.String()
 }
 
-func (TextFormatter) Fields(fields []*Field) string {
+func (t TextFormatter) Fields(fields []*Field) string {
 	builder := strings.Builder{}
 	builder.WriteString(NewLine)
 	builder.WriteString("Fields: ")
 
 	for _, f := range fields {
+		if !f.Exported() && t.ExcludeUnexportedFields {
+			continue
+		}
+
 		builder.WriteString(NewLine)
-		builder.WriteString(fmt.Sprintf("- %v: %v", f.Name(), f.Type()))
+		builder.WriteString(fmt.Sprintf("- %v: %v", f.Key(), f.Type().TypeString(t.Qualifier)))
+		if !f.Exported() {
+			builder.WriteString(" (unexported)")
+		}
+		if desc := f.Description(); desc != "" {
+			builder.WriteString(fmt.Sprintf(" // %v", strings.TrimSpace(desc)))
+		}
 	}
 
 	return builder.String()
 }
 
-func (TextFormatter) Methods(methods []*Method) string {
+func (t TextFormatter) Methods(methods []*Method) string {
 	builder := strings.Builder{}
 	builder.WriteString(NewLine)
 	builder.WriteString("Methods: ")
 
 	for _, m := range methods {
+		if !m.Exported() && t.ExcludeUnexportedMethods {
+			continue
+		}
+
 		builder.WriteString(NewLine)
-		builder.WriteString(fmt.Sprintf("- %v: %v %v", m.Name(), m.signature.Params(), m.signature.Results()))
+		builder.WriteString(fmt.Sprintf("- %v: %v %v", m.Name(),
+			types.TypeString(m.signature.Params(), t.Qualifier),
+			types.TypeString(m.signature.Results(), t.Qualifier)))
+		if !m.Exported() {
+			builder.WriteString(" (unexported)")
+		}
 	}
 
 	return builder.String()
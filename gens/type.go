@@ -0,0 +1,250 @@
+package gens
+
+import (
+	"go/types"
+)
+
+const (
+	TypeKindBasic     = "basic"
+	TypeKindPointer   = "pointer"
+	TypeKindSlice     = "slice"
+	TypeKindArray     = "array"
+	TypeKindMap       = "map"
+	TypeKindChan      = "chan"
+	TypeKindStruct    = "struct"
+	TypeKindInterface = "interface"
+	TypeKindFunc      = "func"
+	TypeKindNamed     = "named"
+	TypeKindTypeParam = "typeparam"
+	// TypeKindInvalid is the kind of types which could not be resolved
+	TypeKindInvalid = "invalid"
+)
+
+// Type describes the type of a field, a parameter or a result
+type Type interface {
+	String() string
+	// TypeString returns the type string with packages named by qualifier
+	TypeString(types.Qualifier) string
+	// Kind returns one of the TypeKind constants
+	Kind() string
+	// Name returns the name of basic, named and type parameter types
+	Name() string
+	// PkgPath returns the import path of the package a named type is declared in
+	PkgPath() string
+	// Elem returns the element type of pointer, slice, array, map and chan types
+	Elem() Type
+	// Key returns the key type of map types
+	Key() Type
+	// Len returns the length of array types
+	Len() int64
+	// TypeArgs returns type arguments of instantiated named types
+	TypeArgs() []Type
+	// Params returns parameter types of func types
+	Params() []Type
+	// Results returns result types of func types
+	Results() []Type
+	// Invalid reports whether the type or one of its components could not
+	// be resolved, e.g. because its package failed to load
+	Invalid() bool
+}
+
+type goType struct {
+	typ types.Type
+}
+
+func newType(typ types.Type) Type {
+	return &goType{typ: typ}
+}
+
+func (t *goType) String() string {
+	return t.typ.String()
+}
+
+func (t *goType) TypeString(qualifier types.Qualifier) string {
+	return types.TypeString(t.typ, qualifier)
+}
+
+// actual returns the type t denotes, aliases are resolved to their target
+func (t *goType) actual() types.Type {
+	return types.Unalias(t.typ)
+}
+
+func (t *goType) Kind() string {
+	if _, ok := typeParamName(t.actual()); ok {
+		return TypeKindTypeParam
+	}
+
+	switch typ := t.actual().(type) {
+	case *types.Basic:
+		if typ.Kind() == types.Invalid {
+			return TypeKindInvalid
+		}
+		return TypeKindBasic
+	case *types.Pointer:
+		return TypeKindPointer
+	case *types.Slice:
+		return TypeKindSlice
+	case *types.Array:
+		return TypeKindArray
+	case *types.Map:
+		return TypeKindMap
+	case *types.Chan:
+		return TypeKindChan
+	case *types.Struct:
+		return TypeKindStruct
+	case *types.Interface:
+		return TypeKindInterface
+	case *types.Signature:
+		return TypeKindFunc
+	case *types.Named:
+		return TypeKindNamed
+	}
+	return ""
+}
+
+func (t *goType) Name() string {
+	if name, ok := typeParamName(t.actual()); ok {
+		return name
+	}
+
+	switch typ := t.actual().(type) {
+	case *types.Basic:
+		return typ.Name()
+	case *types.Named:
+		return typ.Obj().Name()
+	}
+	return ""
+}
+
+func (t *goType) PkgPath() string {
+	if named, ok := t.actual().(*types.Named); ok && named.Obj().Pkg() != nil {
+		return named.Obj().Pkg().Path()
+	}
+	return ""
+}
+
+func (t *goType) Elem() Type {
+	switch typ := t.actual().(type) {
+	case *types.Pointer:
+		return newType(typ.Elem())
+	case *types.Slice:
+		return newType(typ.Elem())
+	case *types.Array:
+		return newType(typ.Elem())
+	case *types.Map:
+		return newType(typ.Elem())
+	case *types.Chan:
+		return newType(typ.Elem())
+	}
+	return nil
+}
+
+func (t *goType) Key() Type {
+	if typ, ok := t.actual().(*types.Map); ok {
+		return newType(typ.Key())
+	}
+	return nil
+}
+
+func (t *goType) Len() int64 {
+	if typ, ok := t.actual().(*types.Array); ok {
+		return typ.Len()
+	}
+	return 0
+}
+
+func (t *goType) TypeArgs() []Type {
+	named, ok := t.actual().(*types.Named)
+	if !ok {
+		return nil
+	}
+
+	return newTypes(typeArgTypes(named))
+}
+
+func (t *goType) Params() []Type {
+	sig, ok := t.actual().(*types.Signature)
+	if !ok {
+		return nil
+	}
+
+	return newTupleTypes(sig.Params())
+}
+
+func (t *goType) Results() []Type {
+	sig, ok := t.actual().(*types.Signature)
+	if !ok {
+		return nil
+	}
+
+	return newTupleTypes(sig.Results())
+}
+
+func (t *goType) Invalid() bool {
+	return containsInvalid(t.typ, map[types.Type]bool{})
+}
+
+func containsInvalid(typ types.Type, seen map[types.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch t := typ.(type) {
+	case *types.Alias:
+		return containsInvalid(types.Unalias(t), seen)
+	case *types.Basic:
+		return t.Kind() == types.Invalid
+	case *types.Pointer:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Slice:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Array:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Chan:
+		return containsInvalid(t.Elem(), seen)
+	case *types.Map:
+		return containsInvalid(t.Key(), seen) || containsInvalid(t.Elem(), seen)
+	case *types.Tuple:
+		for loop := 0; loop < t.Len(); loop++ {
+			if containsInvalid(t.At(loop).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return containsInvalid(t.Params(), seen) || containsInvalid(t.Results(), seen)
+	case *types.Struct:
+		for loop := 0; loop < t.NumFields(); loop++ {
+			if containsInvalid(t.Field(loop).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Named:
+		for _, arg := range typeArgTypes(t) {
+			if containsInvalid(arg, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newTypes(typs []types.Type) []Type {
+	if len(typs) == 0 {
+		return nil
+	}
+
+	result := make([]Type, 0, len(typs))
+	for _, typ := range typs {
+		result = append(result, newType(typ))
+	}
+	return result
+}
+
+func newTupleTypes(tuple *types.Tuple) []Type {
+	typs := make([]types.Type, 0, tuple.Len())
+	for loop := 0; loop < tuple.Len(); loop++ {
+		typs = append(typs, tuple.At(loop).Type())
+	}
+	return newTypes(typs)
+}
@@ -0,0 +1,7 @@
+package gens
+
+// TypeParam is a type parameter of a generic struct with its constraint
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
@@ -0,0 +1,100 @@
+package gens
+
+import "go/types"
+
+// typeParams returns type parameters a generic named type is declared with,
+// constraints name packages with qualifier
+func typeParams(named *types.Named, qualifier types.Qualifier) []*TypeParam {
+	tparams := named.TypeParams()
+	if tparams == nil || tparams.Len() == 0 {
+		return nil
+	}
+
+	params := make([]*TypeParam, 0, tparams.Len())
+	for loop := 0; loop < tparams.Len(); loop++ {
+		tp := tparams.At(loop)
+		params = append(params, &TypeParam{
+			Name:       tp.Obj().Name(),
+			Constraint: types.TypeString(tp.Constraint(), qualifier),
+		})
+	}
+	return params
+}
+
+// typeArgs returns type arguments of an instantiated named type, naming
+// packages with qualifier
+func typeArgs(named *types.Named, qualifier types.Qualifier) []string {
+	targs := named.TypeArgs()
+	if targs == nil || targs.Len() == 0 {
+		return nil
+	}
+
+	args := make([]string, 0, targs.Len())
+	for loop := 0; loop < targs.Len(); loop++ {
+		args = append(args, types.TypeString(targs.At(loop), qualifier))
+	}
+	return args
+}
+
+// isInstance reports whether named is instantiated with concrete type arguments
+func isInstance(named *types.Named) bool {
+	targs := named.TypeArgs()
+	if targs == nil || targs.Len() == 0 {
+		return false
+	}
+
+	for loop := 0; loop < targs.Len(); loop++ {
+		if hasTypeParam(targs.At(loop)) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTypeParam(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return hasTypeParam(t.Elem())
+	case *types.Slice:
+		return hasTypeParam(t.Elem())
+	case *types.Array:
+		return hasTypeParam(t.Elem())
+	case *types.Chan:
+		return hasTypeParam(t.Elem())
+	case *types.Map:
+		return hasTypeParam(t.Key()) || hasTypeParam(t.Elem())
+	case *types.Named:
+		targs := t.TypeArgs()
+		for loop := 0; targs != nil && loop < targs.Len(); loop++ {
+			if hasTypeParam(targs.At(loop)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeArgTypes returns type arguments of an instantiated named type
+func typeArgTypes(named *types.Named) []types.Type {
+	targs := named.TypeArgs()
+	if targs == nil || targs.Len() == 0 {
+		return nil
+	}
+
+	typs := make([]types.Type, 0, targs.Len())
+	for loop := 0; loop < targs.Len(); loop++ {
+		typs = append(typs, targs.At(loop))
+	}
+	return typs
+}
+
+// typeParamName returns the name of typ if it is a type parameter
+func typeParamName(typ types.Type) (string, bool) {
+	tp, ok := typ.(*types.TypeParam)
+	if !ok {
+		return "", false
+	}
+	return tp.Obj().Name(), true
+}
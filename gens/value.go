@@ -0,0 +1,104 @@
+package gens
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+)
+
+const (
+	ValueConst = "const"
+	ValueVar   = "var"
+)
+
+// ValueBlock is a const or var declaration of a package, the values of a
+// parenthesized block stay together
+type ValueBlock struct {
+	// Kind is ValueConst or ValueVar
+	Kind    string
+	Comment string
+	Values  []*Value
+}
+
+// Value is a package-level constant or variable
+type Value struct {
+	Comment string
+	// Source is the type expression a variable is declared with
+	Source string
+	obj    types.Object
+}
+
+// newValueBlocks returns exported constants and variables declared at the
+// top level of file whose name matches filter, nil filter matches all
+func newValueBlocks(pkg *types.Package, file *ast.File, filter *regexp.Regexp, docs docs, sources sources) []*ValueBlock {
+	blocks := make([]*ValueBlock, 0)
+
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || (decl.Tok != token.CONST && decl.Tok != token.VAR) {
+			continue
+		}
+
+		block := &ValueBlock{Kind: ValueVar}
+		if decl.Tok == token.CONST {
+			block.Kind = ValueConst
+		}
+		// doc of a single spec declaration is the one of its value
+		if decl.Doc != nil && len(decl.Specs) > 1 {
+			block.Comment = decl.Doc.Text()
+		}
+
+		for _, spec := range decl.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if !name.IsExported() || (filter != nil && !filter.MatchString(name.Name)) {
+					continue
+				}
+
+				obj := pkg.Scope().Lookup(name.Name)
+				if obj == nil || obj.Pos() != name.Pos() {
+					continue
+				}
+
+				block.Values = append(block.Values, &Value{
+					Comment: docs[name.Pos()],
+					Source:  sources[name.Pos()],
+					obj:     obj,
+				})
+			}
+		}
+
+		if len(block.Values) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks
+}
+
+func (v *Value) Name() string {
+	return v.obj.Name()
+}
+
+// Type returns the type of value, untyped constants have an untyped basic type
+func (v *Value) Type() Type {
+	return newType(v.obj.Type())
+}
+
+// Value returns the computed value of a constant, empty for variables
+func (v *Value) Value() string {
+	c, ok := v.obj.(*types.Const)
+	if !ok {
+		return ""
+	}
+	return constantString(c.Val())
+}
+
+// constantString returns string constants unquoted and other ones exactly
+func constantString(val constant.Value) string {
+	if val.Kind() == constant.String {
+		return constant.StringVal(val)
+	}
+	return val.ExactString()
+}
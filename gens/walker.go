@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -16,11 +17,28 @@ import (
 
 type Walker struct {
 	config.Config
-	BaseDir   string
+	BaseDirs []string
+	// Patterns are go package patterns loaded along with BaseDirs
+	Patterns  []string
 	Recursive bool
 	Filter    *regexp.Regexp
-	LimitOne  bool
-	BuildTags []string
+	// ValueFilter selects package constants and variables by name, nil selects all
+	ValueFilter *regexp.Regexp
+	LimitOne    bool
+	BuildTags   []string
+	GOOS        string
+	GOARCH      string
+	// Mode chooses how packages are loaded, ModePackages or ModeSyntax
+	Mode string
+	// Doc is the rendering of doc comments, DocRaw, DocPlain, DocMarkdown or DocHTML
+	Doc string
+	// IncludeTests also loads structs declared in _test.go files
+	IncludeTests bool
+	// IncludeUnexported also reports unexported fields and methods
+	IncludeUnexported bool
+	// Overlay maps paths of go files to contents type-checked in place of
+	// the ones on disk, e.g. unsaved editor buffers
+	Overlay map[string][]byte
 }
 
 type WalkerVisitor interface {
@@ -33,28 +51,131 @@ func (this *Walker) Walk(ctx context.Context, visitor WalkerVisitor) (generated
 
 	log.Info().Msgf("Walking")
 
-	parser := NewParser(this.BuildTags)
-	this.doWalk(ctx, parser, this.BaseDir, visitor)
-
-	if err := parser.Load(); err != nil {
+	structs, err := this.Structs(ctx)
+	if err != nil {
 		fmt.Printf("Error walking: %v", err)
 	}
 
-	for _, str := range parser.Structs() {
-		if this.Filter != nil && !this.Filter.MatchString(str.Name) {
-			continue
-		}
-
+	for _, str := range structs {
 		if err := visitor.VisitStruct(ctx, str); err != nil {
 			fmt.Fprintf(os.Stderr, "Error walking %s: %s\n", str.Name, err)
 			os.Exit(1)
 		}
+
+		generated = true
 	}
 
 	return
 }
 
-func (this *Walker) doWalk(ctx context.Context, p *Parser, dir string, visitor WalkerVisitor) (generated bool) {
+// Structs loads packages of the walked directories, patterns and overlay
+// files and returns the structs, interfaces, generic instances, enums and
+// package infos emitted, those matching Filter, only the first with LimitOne.
+// Structs found in packages without errors are returned along with the error
+func (this *Walker) Structs(ctx context.Context) ([]*Struct, error) {
+	log := zerolog.Ctx(ctx)
+
+	parser := NewParser(this.BuildTags, this.GOOS, this.GOARCH)
+	parser.SetMode(this.Mode)
+	parser.SetTests(this.IncludeTests)
+	parser.SetValueFilter(this.ValueFilter)
+	parser.SetUnexported(this.IncludeUnexported)
+	parser.SetQualifier(Qualifier{
+		Mode:    this.Config.Qualifier,
+		Aliases: this.Config.Aliases,
+	})
+	if this.Doc != "" && this.Doc != DocRaw {
+		parser.SetDocRenderer(&DocRenderer{Format: this.Doc, Link: this.docLink})
+	}
+	if err := parser.SetOverlay(this.Overlay); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range this.BaseDirs {
+		this.doWalk(ctx, parser, dir)
+	}
+	for _, pattern := range this.Patterns {
+		if err := parser.AddPattern(ctx, pattern); err != nil {
+			log.Err(err).Msgf("Error adding pattern %s", pattern)
+		}
+	}
+
+	overlaid := make([]string, 0, len(this.Overlay))
+	for path := range this.Overlay {
+		overlaid = append(overlaid, path)
+	}
+	sort.Strings(overlaid)
+	for _, path := range overlaid {
+		if err := parser.Parse(ctx, path); err != nil {
+			log.Err(err).Msgf("Error parsing overlay %s", path)
+		}
+	}
+
+	err := parser.Load()
+
+	structs := this.selected(parser)
+	if this.Doc != "" && this.Doc != DocRaw {
+		// doc links point to the infos emitted, structs are built again
+		// with the rendered comments
+		parser.RenderDocs(structs)
+		structs = this.selected(parser)
+	}
+	for _, d := range parser.AllDiagnostics() {
+		log.Warn().Msgf("%v", d)
+	}
+
+	return structs, err
+}
+
+// selected returns the structs, interfaces, generic instances, enums and
+// package infos of parser matching Filter, only the first one with LimitOne
+func (this *Walker) selected(parser *Parser) []*Struct {
+	structs := append(parser.Structs(), parser.Interfaces()...)
+	structs = append(structs, parser.Instances()...)
+	structs = append(structs, parser.Enums()...)
+	structs = append(structs, parser.Packages()...)
+
+	selected := make([]*Struct, 0, len(structs))
+	for _, str := range structs {
+		if this.Filter != nil && !this.Filter.MatchString(str.Name) {
+			continue
+		}
+
+		str.Module = parser.Module(str.pkg)
+		str.Diagnostics = parser.Diagnostics(str.pkg, str.FileName)
+		selected = append(selected, str)
+		if this.LimitOne {
+			break
+		}
+	}
+	return selected
+}
+
+// docLink returns the path of the info generated for type name declared in
+// toFile, relative to the directory infos of fromFile are written to
+func (this *Walker) docLink(fromFile, name, toFile string) (string, bool) {
+	var (
+		osp       = &FileOutputStreamProvider{Config: this.Config}
+		extension = newFormat(this.Config.Format).Extension()
+	)
+
+	from, err := osp.Path(&Struct{FileName: fromFile}, extension)
+	if err != nil {
+		return "", false
+	}
+	to, err := osp.Path(&Struct{Name: name, FileName: toFile}, extension)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func (this *Walker) doWalk(ctx context.Context, p *Parser, dir string) (generated bool) {
 	log := zerolog.Ctx(ctx)
 	ctx = log.WithContext(ctx)
 
@@ -72,7 +193,7 @@ func (this *Walker) doWalk(ctx context.Context, p *Parser, dir string, visitor W
 
 		if file.IsDir() {
 			if this.Recursive {
-				generated = this.doWalk(ctx, p, path, visitor) || generated
+				generated = this.doWalk(ctx, p, path) || generated
 				if generated && this.LimitOne {
 					return
 				}
@@ -80,7 +201,7 @@ func (this *Walker) doWalk(ctx context.Context, p *Parser, dir string, visitor W
 			continue
 		}
 
-		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+		if !strings.HasSuffix(path, ".go") || (strings.HasSuffix(path, "_test.go") && !this.IncludeTests) {
 			continue
 		}
 
@@ -94,6 +215,15 @@ func (this *Walker) doWalk(ctx context.Context, p *Parser, dir string, visitor W
 	return
 }
 
+// newFormat returns the format named name, JF1 when it is unknown
+func newFormat(name string) Format {
+	switch name {
+	case "jf2":
+		return NewJF2()
+	}
+	return NewJF1()
+}
+
 type GeneratorVisitor struct {
 	config.Config
 	InPackage         bool
@@ -113,13 +243,18 @@ func (gv *GeneratorVisitor) VisitStruct(ctx context.Context, str *Struct) error
 			return
 		}
 	}()
-	var format Format
-	switch gv.Config.Format {
-	case "jf2":
-		format = NewJF2()
-	default:
-		format = NewJF1()
-	}
+	format := newFormat(gv.Config.Format)
+	format.SetOptions(FormatOptions{
+		KeySource:  gv.Config.KeySource,
+		MaxDepth:   gv.Config.MaxDepth,
+		References: gv.Config.References,
+		Qualifier: Qualifier{
+			Mode:    gv.Config.Qualifier,
+			Aliases: gv.Config.Aliases,
+		},
+		ExcludeUnexportedFields:  gv.Config.ExcludeUnexportedFields,
+		ExcludeUnexportedMethods: gv.Config.ExcludeUnexportedMethods,
+	})
 
 	out, err, closer := gv.Osp.GetStructWriter(ctx, str, format.Extension())
 	if err != nil {
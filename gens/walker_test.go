@@ -0,0 +1,141 @@
+package gens
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gitlab.id.vin/nam.nguyen10/typeinfo/config"
+	"golang.org/x/tools/go/packages"
+)
+
+// exampleDirs are the examples loading without private modules,
+// examples/fact imports gitlab.id.vin/gami ones
+var exampleDirs = []string{
+	"../examples",
+	"../examples/external",
+	"../examples/jf",
+	"../examples/pointers",
+	"../examples/recursive",
+}
+
+// BenchmarkWalkExamples compares loading the examples with one
+// packages.Load call against one call per file, the way files were loaded
+// before. Both fail when the examples do not load
+func BenchmarkWalkExamples(b *testing.B) {
+	b.Run("batched", func(b *testing.B) {
+		for loop := 0; loop < b.N; loop++ {
+			walker := Walker{}
+			parser := NewParser(nil, "", "")
+			for _, dir := range exampleDirs {
+				walker.doWalk(context.Background(), parser, dir)
+			}
+			if err := parser.Load(); err != nil {
+				b.Fatalf("loading examples: %v", err)
+			}
+		}
+	})
+
+	b.Run("per-file", func(b *testing.B) {
+		files := make([]string, 0)
+		for _, dir := range exampleDirs {
+			matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, path := range matches {
+				if strings.HasSuffix(path, "_test.go") {
+					continue
+				}
+				path, err = filepath.Abs(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				files = append(files, path)
+			}
+		}
+		b.ResetTimer()
+
+		for loop := 0; loop < b.N; loop++ {
+			conf := NewParser(nil, "", "").conf
+			for _, file := range files {
+				pkgs, err := packages.Load(&conf, "file="+file)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, pkg := range pkgs {
+					if len(pkg.Errors) > 0 {
+						b.Fatalf("loading %s: %v", file, pkg.Errors[0])
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestDocLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/fact\n\ngo 1.21\n",
+		"fact.go": "package fact\n\n// Fact holds a [Rule] and a [Campaign]\ntype Fact struct{}\n\ntype Rule struct{}\n\ntype Campaign struct{}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   string
+		limitOne bool
+		want     string
+	}{
+		{
+			name:   "emitted types are linked to their info",
+			filter: "^(Fact|Rule|Campaign)$",
+			want:   "Fact holds a [Rule](Rule.json) and a [Campaign](Campaign.json)\n",
+		},
+		{
+			name:   "filtered out types point to pkg.go.dev",
+			filter: "^(Fact|Rule)$",
+			want:   "Fact holds a [Rule](Rule.json) and a [Campaign](https://pkg.go.dev/example.com/fact#Campaign)\n",
+		},
+		{
+			name:     "types left out by LimitOne point to pkg.go.dev",
+			filter:   "^Fact$",
+			limitOne: true,
+			want:     "Fact holds a [Rule](https://pkg.go.dev/example.com/fact#Rule) and a [Campaign](https://pkg.go.dev/example.com/fact#Campaign)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walker := Walker{
+				Config:   config.Config{Output: "infos", Format: "jf2"},
+				BaseDirs: []string{dir},
+				Filter:   regexp.MustCompile(tt.filter),
+				LimitOne: tt.limitOne,
+				Doc:      DocMarkdown,
+			}
+			structs, err := walker.Structs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, str := range structs {
+				if str.Name != "Fact" {
+					continue
+				}
+				if str.Comment != tt.want {
+					t.Errorf("Comment = %q, want %q", str.Comment, tt.want)
+				}
+				return
+			}
+			t.Fatal("Fact is not emitted")
+		})
+	}
+}
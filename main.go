@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -19,14 +21,16 @@ import (
 
 var (
 	rootCmd = &cobra.Command{
-		Use:   "typeinfo",
+		Use:   "typeinfo [packages]",
 		Short: "Generate information for your struct",
+		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r, err := GetRootAppFromViper(viper.GetViper())
 			if err != nil {
 				printStackTrace(err)
 				return err
 			}
+			r.Config.Patterns = args
 			return r.Run()
 		},
 	}
@@ -55,15 +59,32 @@ func main() {
 func init() {
 	pFlags := rootCmd.PersistentFlags()
 	pFlags.String("name", "", "name or matching regular expression of interface to generate info for")
+	pFlags.String("values", "", "name or matching regular expression of package constants and variables to generate info for, all exported ones when empty")
 	pFlags.String("output", "./infos", "directory to write generated infos to")
 	pFlags.String("format", "jf", "file format info will be saved to [jf1 jf2]")
-	pFlags.String("dir", ".", "directory to search for generating struct")
+	pFlags.StringSlice("dir", nil, "directories to search for generating struct, the current one when no package pattern is given")
 	pFlags.BoolP("recursive", "r", false, "recurse search into sub-directories")
-	pFlags.Bool("all", false, "generates info for all struct that found in directory")
+	pFlags.Bool("all", false, "generates info for all struct, interface and func type that found in directory")
 	pFlags.String("case", "camel", "naming the generated file following convention [camel, snake, underscore]")
 	pFlags.Bool("version", false, "prints the installed version of tinfo")
 	pFlags.Bool("keeptree", false, "keep the hierarchy tree of the generated files that same as the original")
 	pFlags.String("filename", "", "name of generated file (only works with --name and no regex)")
+	pFlags.String("mode", gens.ModePackages, "how packages are loaded [packages, syntax], syntax parses sources alone without resolving dependencies")
+	pFlags.String("stdin", "", "path of a go file whose contents are read from stdin in place of the ones on disk")
+	pFlags.Bool("include-tests", false, "also generates info for structs declared in _test.go files")
+	pFlags.Bool("include-unexported", false, "also generates info for unexported fields and methods, marked as unexported")
+	pFlags.Bool("exclude-unexported-fields", false, "leaves unexported fields out of generated info instead of marking them, with --include-unexported")
+	pFlags.Bool("exclude-unexported-methods", false, "leaves unexported methods out of generated info instead of marking them, with --include-unexported")
+	pFlags.StringSlice("tags", nil, "build tags packages are loaded with")
+	pFlags.String("goos", "", "GOOS packages are loaded for, the environment one when empty")
+	pFlags.String("goarch", "", "GOARCH packages are loaded for, the environment one when empty")
+	pFlags.StringSlice("build-config", nil, "generates one output per build configuration GOOS/GOARCH[:tag+tag], e.g. linux/amd64,windows/amd64:integration")
+	pFlags.Int("max-depth", 0, "maximum levels of nested structs laid out, 0 means no limit")
+	pFlags.Bool("refs", false, "emit each nested struct once and refer to it with $ref afterward, jf2 only")
+	pFlags.String("qualifier", "full", "naming of packages in type strings [full, package, relative, alias]")
+	pFlags.StringToString("alias", nil, "aliases of import path prefixes used by --qualifier alias, e.g. gitlab.id.vin/gami=gami")
+	pFlags.String("doc", gens.DocRaw, "rendering of doc comments [raw, plain, markdown, html], doc links to generated types point to their info")
+	pFlags.String("key-source", "go-name", "source of emitted field keys [go-name, json, yaml] or any struct tag key")
 
 	_ = viper.BindPFlags(pFlags)
 }
@@ -118,30 +139,86 @@ func (r *RootApp) Run() error {
 		log.Fatal().Msgf("Use --name to specify the name of the struct or --all for all structs found")
 	}
 
+	var valueFilter *regexp.Regexp
+	if r.Config.Values != "" {
+		expr := r.Config.Values
+		if !strings.ContainsAny(expr, regexMetadataChars) {
+			expr = fmt.Sprintf("^%s$", expr)
+		}
+		if valueFilter, err = regexp.Compile(expr); err != nil {
+			log.Fatal().Err(err).Msgf("Invalid regular expression provided to -values")
+		}
+	}
+
 	if r.Config.Format == "" {
 		log.Warn().Msgf("Format is empty, default value json will be used instead")
 	}
-
-	osp := &gens.FileOutputStreamProvider{
-		Config: r.Config,
+	if r.Config.References && r.Config.Format != "jf2" {
+		log.Fatal().Msgf("--refs needs --format jf2, jf1 flattens nested structs into dotted keys that $ref cannot point to")
 	}
 
-	baseDir := r.Config.Directory
+	var overlay map[string][]byte
+	if r.Config.Stdin != "" {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to read stdin")
+		}
+		overlay = map[string][]byte{r.Config.Stdin: contents}
+	}
 
-	visitor := &gens.GeneratorVisitor{
-		Config: r.Config,
-		Osp:    osp,
+	if len(r.Config.Directories) == 0 && len(r.Config.Patterns) == 0 && overlay == nil {
+		r.Config.Directories = []string{"."}
 	}
 
-	walker := gens.Walker{
-		Config:    r.Config,
-		BaseDir:   baseDir,
-		Recursive: recursive,
-		Filter:    filter,
-		LimitOne:  limitOne,
+	builds := []gens.BuildConfig{{GOOS: r.Config.GOOS, GOARCH: r.Config.GOARCH}}
+	if len(r.Config.BuildConfigs) > 0 {
+		builds = builds[:0]
+		for _, value := range r.Config.BuildConfigs {
+			build, err := gens.ParseBuildConfig(value)
+			if err != nil {
+				log.Fatal().Err(err).Msgf("Invalid --build-config")
+			}
+			builds = append(builds, build)
+		}
 	}
 
-	generated := walker.Walk(ctx, visitor)
+	var generated bool
+	for _, build := range builds {
+		conf := r.Config
+		if len(r.Config.BuildConfigs) > 0 {
+			// one output per build configuration
+			conf.Output = filepath.Join(conf.Output, build.Name())
+		}
+
+		osp := &gens.FileOutputStreamProvider{
+			Config: conf,
+		}
+
+		visitor := &gens.GeneratorVisitor{
+			Config: conf,
+			Osp:    osp,
+		}
+
+		walker := gens.Walker{
+			Config:            conf,
+			BaseDirs:          conf.Directories,
+			Patterns:          conf.Patterns,
+			Recursive:         recursive,
+			Filter:            filter,
+			ValueFilter:       valueFilter,
+			LimitOne:          limitOne,
+			BuildTags:         append(append([]string{}, conf.Tags...), build.Tags...),
+			GOOS:              build.GOOS,
+			GOARCH:            build.GOARCH,
+			Mode:              conf.Mode,
+			Doc:               conf.Doc,
+			IncludeTests:      conf.IncludeTests,
+			IncludeUnexported: conf.IncludeUnexported,
+			Overlay:           overlay,
+		}
+
+		generated = walker.Walk(ctx, visitor) || generated
+	}
 
 	if r.Config.Name != "" && !generated {
 		log.Fatal().Msgf("Unable to find '%s' in any go files under this path", r.Config.Name)